@@ -0,0 +1,149 @@
+package api
+
+import (
+	"net/http"
+	"singo/req"
+	"singo/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 强制用户下线接口
+// @Description 撤销指定用户的全部会话
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.UserRevokeReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/user/revoke [post]
+func AdminRevokeUser(c *gin.Context) {
+	var param service.UserRevokeReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.RevokeUser(&param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 解除账号锁定接口
+// @Description 清除账号的登录失败次数并解除锁定
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.UserUnlockReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/user/unlock [post]
+func AdminUnlockUser(c *gin.Context) {
+	var param service.UserUnlockReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.UnlockUser(&param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 封禁用户接口
+// @Description 封禁用户并撤销其全部会话，可设置到期时间
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.UserSuspendReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/user/suspend [post]
+func AdminSuspendUser(c *gin.Context) {
+	var param service.UserSuspendReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.SuspendUser(c.Request.Context(), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 解除封禁接口
+// @Description 解除用户封禁
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.UserModerateReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/user/reactivate [post]
+func AdminReactivateUser(c *gin.Context) {
+	var param service.UserModerateReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.ReactivateUser(c.Request.Context(), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 删除用户接口
+// @Description 软删除用户并撤销其全部会话
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.UserModerateReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/user/delete [post]
+func AdminDeleteUser(c *gin.Context) {
+	var param service.UserModerateReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.DeleteUser(c.Request.Context(), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 模拟用户登录接口
+// @Description 以指定用户身份登录用于排查问题，颁发短期Token并写入审计日志，模拟期间禁止敏感操作
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.UserImpersonateReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.UserReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/user/impersonate [post]
+func AdminImpersonateUser(c *gin.Context) {
+	var param service.UserImpersonateReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.Impersonate(c.GetString("username"), &param, clientInfo(c))
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 审计日志接口
+// @Description 分页获取审计日志
+// @Tags 管理
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request query req.PageReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.Pagination{items=[]data.AuditLogReq}} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/audit-logs [get]
+func AdminAuditLogs(c *gin.Context) {
+	var param req.PageReq
+	if err := c.ShouldBindQuery(&param); err == nil {
+		res := service.ListAuditLogs(&param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
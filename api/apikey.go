@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"singo/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 创建API Key接口
+// @Description 创建个人API Key，完整Key仅返回一次，请求时放在X-Api-Key请求头中
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.ApiKeyCreateReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.ApiKeyReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/apikeys [post]
+func ApiKeyCreate(c *gin.Context) {
+	var param service.ApiKeyCreateReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.CreateApiKey(c.Request.Context(), c.GetString("username"), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary API Key列表接口
+// @Description 列出个人全部API Key
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Success 200 {object} data.Response{data=[]data.ApiKeyReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/apikeys [get]
+func ApiKeys(c *gin.Context) {
+	res := service.ListApiKeys(c.GetString("username"))
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary 删除API Key接口
+// @Description 删除个人API Key
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param id path int true "API Key编号"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/apikeys/{id} [delete]
+func ApiKeyDelete(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+	res := service.DeleteApiKey(c.Request.Context(), c.GetString("username"), id)
+	c.JSON(http.StatusOK, res)
+}
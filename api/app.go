@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"singo/data"
+	"singo/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 开放接口状态检查
+// @Description 校验签名并返回调用方的应用Key
+// @Tags 开放接口
+// @Accept json
+// @Produce json
+// @Param X-App-Key header string true "应用Key"
+// @Param X-Timestamp header string true "Unix时间戳(秒)"
+// @Param X-Nonce header string true "随机数"
+// @Param X-Signature header string true "签名"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 401 {object} data.Response "失败返回"
+// @Router /api/open/v1/ping [get]
+func OpenPing(c *gin.Context) {
+	c.JSON(http.StatusOK, data.NewDataResponse(gin.H{"app_key": c.GetString("app_key")}))
+}
+
+// @Summary 创建开放平台应用接口
+// @Description 创建开放平台应用，应用密钥仅返回一次
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.AppClientCreateReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.AppClientReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/apps [post]
+func AdminAppCreate(c *gin.Context) {
+	var param service.AppClientCreateReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.CreateAppClient(c.Request.Context(), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 开放平台应用列表接口
+// @Description 获取全部开放平台应用
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Success 200 {object} data.Response{data=[]data.AppClientReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/apps [get]
+func AdminApps(c *gin.Context) {
+	c.JSON(http.StatusOK, service.ListAppClients())
+}
+
+// @Summary 修改开放平台应用状态接口
+// @Description 启用或停用开放平台应用
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param app_key path string true "应用Key"
+// @Param request body service.AppClientStatusReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/apps/{app_key} [put]
+func AdminAppStatus(c *gin.Context) {
+	var param service.AppClientStatusReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.UpdateAppClientStatus(c.Request.Context(), c.Param("app_key"), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 删除开放平台应用接口
+// @Description 删除开放平台应用
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param app_key path string true "应用Key"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/apps/{app_key} [delete]
+func AdminAppDelete(c *gin.Context) {
+	c.JSON(http.StatusOK, service.DeleteAppClient(c.Request.Context(), c.Param("app_key")))
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"singo/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 两步验证登录接口
+// @Description 使用登录返回的两步验证Token及认证器验证码或恢复码完成登录
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body service.MfaLoginReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.UserReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/login/mfa [post]
+func UserLoginMfa(c *gin.Context) {
+	var param service.MfaLoginReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.MfaLogin(&param, clientInfo(c))
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 生成两步验证密钥接口
+// @Description 生成认证器密钥及otpauth地址，确认后才会生效
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Success 200 {object} data.Response{data=data.MfaSetupReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/mfa/setup [post]
+func MfaSetup(c *gin.Context) {
+	res := service.MfaSetup(c.GetString("username"))
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary 开启两步验证接口
+// @Description 校验认证器验证码后开启两步验证，返回恢复码
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.MfaCodeReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.RecoveryCodesReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/mfa/enable [post]
+func MfaEnable(c *gin.Context) {
+	var param service.MfaCodeReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.MfaEnable(c.Request.Context(), c.GetString("username"), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 关闭两步验证接口
+// @Description 校验密码及认证器验证码后关闭两步验证
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.MfaDisableReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/mfa/disable [post]
+func MfaDisable(c *gin.Context) {
+	var param service.MfaDisableReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.MfaDisable(c.Request.Context(), c.GetString("username"), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 重新生成恢复码接口
+// @Description 校验认证器验证码后重新生成恢复码，旧恢复码全部作废
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.MfaCodeReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.RecoveryCodesReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/mfa/recovery-codes [post]
+func MfaRecoveryCodes(c *gin.Context) {
+	var param service.MfaCodeReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.MfaRecoveryCodes(c.Request.Context(), c.GetString("username"), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
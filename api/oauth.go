@@ -0,0 +1,189 @@
+package api
+
+import (
+	"net/http"
+	"singo/data"
+	"singo/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oauthClientAuth 优先使用HTTP Basic认证中的客户端凭证
+func oauthClientAuth(c *gin.Context, clientID, clientSecret *string) {
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		*clientID = id
+		*clientSecret = secret
+	}
+}
+
+// oauthError 按RFC 6749返回错误，客户端认证失败返回401
+func oauthError(c *gin.Context, err *data.OAuthError) {
+	status := http.StatusBadRequest
+	if err.Error == data.OAuthInvalidClient {
+		status = http.StatusUnauthorized
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	c.JSON(status, err)
+}
+
+// @Summary OAuth2授权确认信息接口
+// @Description 校验授权请求并返回授权确认页面所需信息
+// @Tags OAuth2
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request query service.OAuthAuthorizeReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.OAuthConsentReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /oauth/authorize [get]
+func OAuthAuthorizeInfo(c *gin.Context) {
+	var param service.OAuthAuthorizeReq
+	if err := c.ShouldBindQuery(&param); err == nil {
+		res := service.AuthorizeInfo(c.GetString("username"), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary OAuth2授权确认接口
+// @Description 用户同意或拒绝授权，返回携带授权码的回调地址
+// @Tags OAuth2
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.OAuthApproveReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.OAuthRedirectReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /oauth/authorize [post]
+func OAuthAuthorize(c *gin.Context) {
+	var param service.OAuthApproveReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.Authorize(c.Request.Context(), c.GetString("username"), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary OAuth2 Token接口
+// @Description 支持authorization_code(PKCE)、client_credentials及refresh_token授权类型
+// @Tags OAuth2
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param request formData service.OAuthTokenReq true "请求参数"
+// @Success 200 {object} data.OAuthToken "成功返回"
+// @Failure 400 {object} data.OAuthError "失败返回"
+// @Router /oauth/token [post]
+func OAuthToken(c *gin.Context) {
+	var param service.OAuthTokenReq
+	if err := c.ShouldBind(&param); err != nil {
+		c.JSON(http.StatusBadRequest, data.NewOAuthError(data.OAuthInvalidRequest, err.Error()))
+		return
+	}
+	oauthClientAuth(c, &param.ClientID, &param.ClientSecret)
+
+	c.Header("Cache-Control", "no-store")
+	token, err := service.OAuthToken(&param)
+	if err != nil {
+		oauthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, token)
+}
+
+// @Summary OAuth2 Token内省接口
+// @Description 按RFC 7662返回Token是否有效及其授权信息
+// @Tags OAuth2
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param request formData service.OAuthTokenCheckReq true "请求参数"
+// @Success 200 {object} data.OAuthIntrospection "成功返回"
+// @Failure 400 {object} data.OAuthError "失败返回"
+// @Router /oauth/introspect [post]
+func OAuthIntrospect(c *gin.Context) {
+	var param service.OAuthTokenCheckReq
+	if err := c.ShouldBind(&param); err != nil {
+		c.JSON(http.StatusBadRequest, data.NewOAuthError(data.OAuthInvalidRequest, err.Error()))
+		return
+	}
+	oauthClientAuth(c, &param.ClientID, &param.ClientSecret)
+
+	res, err := service.OAuthIntrospect(&param)
+	if err != nil {
+		oauthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary OAuth2 Token撤销接口
+// @Description 按RFC 7009撤销访问Token或刷新Token
+// @Tags OAuth2
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param request formData service.OAuthTokenCheckReq true "请求参数"
+// @Success 200 "成功返回"
+// @Failure 400 {object} data.OAuthError "失败返回"
+// @Router /oauth/revoke [post]
+func OAuthRevoke(c *gin.Context) {
+	var param service.OAuthTokenCheckReq
+	if err := c.ShouldBind(&param); err != nil {
+		c.JSON(http.StatusBadRequest, data.NewOAuthError(data.OAuthInvalidRequest, err.Error()))
+		return
+	}
+	oauthClientAuth(c, &param.ClientID, &param.ClientSecret)
+
+	if err := service.OAuthRevoke(&param); err != nil {
+		oauthError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// @Summary 注册OAuth2客户端接口
+// @Description 注册OAuth2客户端，机密客户端的密钥仅返回一次
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.OAuthClientCreateReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.OAuthClientReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/oauth/clients [post]
+func AdminOAuthClientCreate(c *gin.Context) {
+	var param service.OAuthClientCreateReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.CreateOAuthClient(c.Request.Context(), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary OAuth2客户端列表接口
+// @Description 获取全部OAuth2客户端
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Success 200 {object} data.Response{data=[]data.OAuthClientReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/oauth/clients [get]
+func AdminOAuthClients(c *gin.Context) {
+	c.JSON(http.StatusOK, service.ListOAuthClients())
+}
+
+// @Summary 删除OAuth2客户端接口
+// @Description 删除OAuth2客户端及用户授权记录
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param client_id path string true "客户端编号"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/oauth/clients/{client_id} [delete]
+func AdminOAuthClientDelete(c *gin.Context) {
+	c.JSON(http.StatusOK, service.DeleteOAuthClient(c.Request.Context(), c.Param("client_id")))
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"singo/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 外部登录接口
+// @Description 返回跳转到外部身份提供方的授权地址
+// @Tags 用户
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param provider path string true "身份提供方名称"
+// @Param request query service.OidcLoginReq false "请求参数"
+// @Success 200 {object} data.Response{data=data.OidcRedirectReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/oidc/{provider}/login [get]
+func OidcLogin(c *gin.Context) {
+	var param service.OidcLoginReq
+	if err := c.ShouldBindQuery(&param); err == nil {
+		res := service.OidcAuthURL(c.Param("provider"), "", &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 外部登录回调接口
+// @Description 身份提供方回调，完成登录、注册或绑定
+// @Tags 用户
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param provider path string true "身份提供方名称"
+// @Param request query service.OidcCallbackReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.UserReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/oidc/{provider}/callback [get]
+func OidcCallback(c *gin.Context) {
+	var param service.OidcCallbackReq
+	if err := c.ShouldBindQuery(&param); err == nil {
+		res := service.OidcCallback(c.Param("provider"), &param, clientInfo(c))
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 绑定外部身份接口
+// @Description 返回跳转到外部身份提供方的授权地址，回调后绑定到当前用户
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param provider path string true "身份提供方名称"
+// @Success 200 {object} data.Response{data=data.OidcRedirectReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/oidc/{provider}/link [post]
+func OidcLink(c *gin.Context) {
+	res := service.OidcAuthURL(c.Param("provider"), c.GetString("username"), &service.OidcLoginReq{})
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary 外部身份列表接口
+// @Description 列出当前用户绑定的外部身份
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Success 200 {object} data.Response{data=[]data.IdentityReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/identities [get]
+func Identities(c *gin.Context) {
+	res := service.ListIdentities(c.GetString("username"))
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary 解除外部身份接口
+// @Description 解除外部身份绑定
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param id path int true "外部身份编号"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/identities/{id} [delete]
+func IdentityDelete(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+	res := service.DeleteIdentity(c.Request.Context(), c.GetString("username"), id)
+	c.JSON(http.StatusOK, res)
+}
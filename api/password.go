@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"singo/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 忘记密码接口
+// @Description 向注册邮箱发送重置密码链接
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body service.PasswordForgotReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/password/forgot [post]
+func PasswordForgot(c *gin.Context) {
+	var param service.PasswordForgotReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.ForgotPassword(&param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 重置密码接口
+// @Description 使用邮件中的重置Token设置新密码，成功后全部会话失效
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body service.PasswordResetReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/password/reset [post]
+func PasswordReset(c *gin.Context) {
+	var param service.PasswordResetReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.ResetPassword(&param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 修改密码接口
+// @Description 校验原密码后修改密码，成功后全部会话失效
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.PasswordChangeReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/password [put]
+func PasswordChange(c *gin.Context) {
+	var param service.PasswordChangeReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.ChangePassword(c.Request.Context(), c.GetString("username"), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 设置密码接口
+// @Description 外部身份或短信验证码注册的用户首次设置密码，已有密码时需使用修改密码接口，成功后全部会话失效
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.PasswordSetReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/password [post]
+func PasswordSet(c *gin.Context) {
+	var param service.PasswordSetReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.SetPassword(c.Request.Context(), c.GetString("username"), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
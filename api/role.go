@@ -0,0 +1,129 @@
+package api
+
+import (
+	"net/http"
+	"singo/data"
+	"singo/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// paramID 获取路径中的数字编号
+func paramID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusOK, data.ParamErr("编号错误"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// @Summary 角色列表接口
+// @Description 获取全部角色及其权限
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Success 200 {object} data.Response{data=[]data.RoleReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/roles [get]
+func AdminRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, service.ListRoles())
+}
+
+// @Summary 权限列表接口
+// @Description 获取全部权限
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Success 200 {object} data.Response{data=[]data.PermissionReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/permissions [get]
+func AdminPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, service.ListPermissions())
+}
+
+// @Summary 创建角色接口
+// @Description 创建角色并设置权限
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.RoleReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.RoleReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/roles [post]
+func AdminRoleCreate(c *gin.Context) {
+	var param service.RoleReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.CreateRole(c.Request.Context(), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 修改角色接口
+// @Description 修改角色描述及权限
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param id path int true "角色编号"
+// @Param request body service.RoleReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.RoleReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/roles/{id} [put]
+func AdminRoleUpdate(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+	var param service.RoleReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.UpdateRole(c.Request.Context(), id, &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 删除角色接口
+// @Description 删除角色及其分配
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param id path int true "角色编号"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/roles/{id} [delete]
+func AdminRoleDelete(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, service.DeleteRole(c.Request.Context(), id))
+}
+
+// @Summary 分配用户角色接口
+// @Description 设置用户的角色，用户需重新登录后生效
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.UserRolesReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/admin/user/roles [put]
+func AdminUserRoles(c *gin.Context) {
+	var param service.UserRolesReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.SetUserRoles(c.Request.Context(), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"net/http"
 	"singo/data"
 	"singo/logger"
+	"singo/middleware"
+	"singo/req"
+	"singo/service"
 )
 
 // @Summary 状态检查
@@ -23,6 +26,16 @@ func Ping(c *gin.Context) {
 	c.JSON(http.StatusOK, data.NewSuccessResponse("Pong"))
 }
 
+// @Summary JWKS公钥接口
+// @Description 返回验证Token签名所需的公钥集合，供下游服务验证Token
+// @Tags 系统
+// @Produce json
+// @Success 200 {object} map[string]interface{} "成功返回"
+// @Router /.well-known/jwks.json [get]
+func JWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, middleware.JWKS())
+}
+
 // ErrorResponse 返回错误消息
 func ErrorResponse(err error) *data.Response {
 	var ve validator.ValidationErrors
@@ -44,3 +57,30 @@ func ErrorResponse(err error) *data.Response {
 	logger.Error("参数错误", err)
 	return data.ParamErr("参数错误")
 }
+
+// clientInfo 获取请求来源信息
+func clientInfo(c *gin.Context) *req.Client {
+	return &req.Client{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// @Summary 获取验证码接口
+// @Description 生成图片或滑块验证码，提交时通过X-Captcha-Id及X-Captcha-Answer请求头携带，滑块验证码的答案为缺口横坐标
+// @Tags 系统
+// @Accept json
+// @Produce json
+// @Param type query string false "验证码类型 image/slider"
+// @Success 200 {object} data.Response{data=data.CaptchaReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/captcha [get]
+func Captcha(c *gin.Context) {
+	var param service.CaptchaReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.NewCaptcha(&param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
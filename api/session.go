@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+	"singo/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary 登录设备列表接口
+// @Description 列出当前用户全部登录设备
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Success 200 {object} data.Response{data=[]data.SessionReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/sessions [get]
+func UserSessions(c *gin.Context) {
+	res := service.ListSessions(c.GetString("username"), c.GetString("session_id"))
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary 注销登录设备接口
+// @Description 远程注销指定登录设备
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param id path string true "会话编号"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/sessions/{id} [delete]
+func UserSessionDelete(c *gin.Context) {
+	res := service.DeleteSession(c.GetString("username"), c.Param("id"))
+	c.JSON(http.StatusOK, res)
+}
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 	"singo/req"
 	"singo/service"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,6 +29,44 @@ func UserRegister(c *gin.Context) {
 	}
 }
 
+// @Summary 邮箱验证接口
+// @Description 打开邮件中的验证链接以激活账号
+// @Tags 用户
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param request query service.UserVerifyReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/verify [get]
+func UserVerify(c *gin.Context) {
+	var param service.UserVerifyReq
+	if err := c.ShouldBindQuery(&param); err == nil {
+		res := service.Verify(&param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 重发验证邮件接口
+// @Description 重新发送邮箱验证邮件
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body service.UserVerifyResendReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/verify/resend [post]
+func UserVerifyResend(c *gin.Context) {
+	var param service.UserVerifyResendReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.VerifyResend(&param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
 // @Summary 用户登录接口
 // @Description 用户登录接口
 // @Tags 用户
@@ -39,7 +79,26 @@ func UserRegister(c *gin.Context) {
 func UserLogin(c *gin.Context) {
 	var param service.UserLoginReq
 	if err := c.ShouldBind(&param); err == nil {
-		res := service.Login(&param)
+		res := service.Login(&param, clientInfo(c))
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 刷新Token接口
+// @Description 使用刷新Token换取新的访问Token，同时轮换刷新Token
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body service.TokenRefreshReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.UserReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/token/refresh [post]
+func UserTokenRefresh(c *gin.Context) {
+	var param service.TokenRefreshReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.RefreshToken(&param)
 		c.JSON(http.StatusOK, res)
 	} else {
 		c.JSON(http.StatusOK, ErrorResponse(err))
@@ -52,11 +111,11 @@ func UserLogin(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "token"
-// @Success 200 {object} data.Response{data=model.User} "成功返回"
+// @Success 200 {object} data.Response{data=data.UserReq} "成功返回"
 // @Failure 400 {object} data.Response "失败返回"
 // @Router /api/v1/user/info [get]
 func UserMe(c *gin.Context) {
-	user := service.Me(c.GetString("username"))
+	user := service.Me(c.GetString("username"), c.GetString("actor"))
 	c.JSON(http.StatusOK, user)
 }
 
@@ -67,7 +126,7 @@ func UserMe(c *gin.Context) {
 // @Produce json
 // @Param request query req.PageUserReq true "请求参数"
 // @Param Authorization header string true "token"
-// @Success 200 {object} data.Response{data=data.Pagination{items=[]model.User}} "成功返回"
+// @Success 200 {object} data.Response{data=data.Pagination{items=[]data.UserReq}} "成功返回"
 // @Failure 400 {object} data.Response "失败返回"
 // @Router /api/v1/user/list [get]
 func Get(c *gin.Context) {
@@ -79,3 +138,173 @@ func Get(c *gin.Context) {
 		c.JSON(http.StatusOK, ErrorResponse(err))
 	}
 }
+
+// @Summary 用户注销接口
+// @Description 用户注销接口，注销后当前设备的Token立即失效
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/logout [post]
+func UserLogout(c *gin.Context) {
+	res := service.Logout(c.GetString("username"), c.GetString("session_id"))
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary 发送短信验证码接口
+// @Description 发送6位短信登录验证码，按手机号及IP限制发送频率
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body service.SmsSendReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/sms/send [post]
+func SmsSend(c *gin.Context) {
+	var param service.SmsSendReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.SendSmsCode(&param, clientInfo(c))
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 短信验证码登录接口
+// @Description 使用短信验证码登录，手机号未注册时自动创建账号
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body service.SmsLoginReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.UserReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/sms/login [post]
+func SmsLogin(c *gin.Context) {
+	var param service.SmsLoginReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.SmsLogin(&param, clientInfo(c))
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 登录历史接口
+// @Description 分页获取当前用户的登录历史，包括失败的登录尝试
+// @Tags 用户
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request query req.PageReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.Pagination{items=[]data.LoginHistoryReq}} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/login-history [get]
+func UserLoginHistory(c *gin.Context) {
+	var param req.PageReq
+	if err := c.ShouldBindQuery(&param); err == nil {
+		res := service.ListLoginHistory(c.GetString("username"), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 修改个人资料接口
+// @Description 修改昵称等个人资料，未填写的字段保持不变
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.UserUpdateReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.UserReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/info [patch]
+func UserUpdate(c *gin.Context) {
+	var param service.UserUpdateReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.UpdateProfile(c.Request.Context(), c.GetString("username"), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 上传头像接口
+// @Description 上传JPEG、PNG或GIF头像，自动裁剪为正方形并生成多个尺寸的缩略图
+// @Tags 用户
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param avatar formData file true "头像图片"
+// @Success 200 {object} data.Response{data=data.AvatarReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/avatar [post]
+func UserAvatar(c *gin.Context) {
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+		return
+	}
+	c.JSON(http.StatusOK, service.UploadAvatar(c.Request.Context(), c.GetString("username"), file))
+}
+
+// @Summary 注销账号接口
+// @Description 注销当前账号，立即撤销全部会话，宽限期后清除个人信息
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "token"
+// @Param request body service.UserDeleteReq true "请求参数"
+// @Success 200 {object} data.Response "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/me [delete]
+func UserDelete(c *gin.Context) {
+	var param service.UserDeleteReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.DeleteAccount(c.Request.Context(), c.GetString("username"), &param)
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 恢复账号接口
+// @Description 注销宽限期内使用用户名及密码或手机号及短信验证码撤销注销，成功后直接登录
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body service.UserRestoreReq true "请求参数"
+// @Success 200 {object} data.Response{data=data.UserReq} "成功返回"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/restore [post]
+func UserRestore(c *gin.Context) {
+	var param service.UserRestoreReq
+	if err := c.ShouldBind(&param); err == nil {
+		res := service.RestoreAccount(&param, clientInfo(c))
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.JSON(http.StatusOK, ErrorResponse(err))
+	}
+}
+
+// @Summary 导出个人数据接口
+// @Description 下载包含个人资料、会话、登录历史等全部数据的zip压缩包
+// @Tags 用户
+// @Accept json
+// @Produce application/zip
+// @Param Authorization header string true "token"
+// @Success 200 {file} file "压缩包"
+// @Failure 400 {object} data.Response "失败返回"
+// @Router /api/v1/user/export [get]
+func UserExport(c *gin.Context) {
+	archive, res := service.ExportUserData(c.GetString("username"))
+	if res != nil {
+		c.JSON(http.StatusOK, res)
+		return
+	}
+	filename := fmt.Sprintf("%s-%s.zip", c.GetString("username"), time.Now().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+func wrapCaptcha(id string) string {
+	return fmt.Sprintf("captcha:%s", id)
+}
+
+// SetCaptcha 存储验证码答案
+func (rep *MyRedis) SetCaptcha(id, answer string, expire time.Duration) (err error) {
+	err = rep.Set(wrapCaptcha(id), answer, expire).Err()
+	return
+}
+
+// TakeCaptcha 取出并删除验证码答案，每个验证码只能校验一次，不存在时返回redis.Nil
+func (rep *MyRedis) TakeCaptcha(id string) (answer string, err error) {
+	var get *redis.StringCmd
+	_, err = rep.TxPipelined(func(pipe redis.Pipeliner) error {
+		get = pipe.Get(wrapCaptcha(id))
+		pipe.Del(wrapCaptcha(id))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return get.Val(), nil
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Impersonation 管理员模拟用户登录的记录
+type Impersonation struct {
+	// 被模拟的用户名
+	UserName string
+	// 实际操作的管理员
+	Actor string
+	// 颁发的Token
+	Token string
+}
+
+func wrapImpersonation(sid string) string {
+	return fmt.Sprintf("impersonate:%s", sid)
+}
+
+// SetImpersonation 存储模拟登录记录，不计入用户的会话列表
+func (rep *MyRedis) SetImpersonation(sid string, imp *Impersonation, expire time.Duration) (err error) {
+	_, err = rep.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HMSet(wrapImpersonation(sid), map[string]interface{}{
+			"username": imp.UserName,
+			"actor":    imp.Actor,
+			"token":    imp.Token,
+		})
+		pipe.Expire(wrapImpersonation(sid), expire)
+		return nil
+	})
+	return
+}
+
+// GetImpersonation 获取模拟登录记录，不存在时返回redis.Nil
+func (rep *MyRedis) GetImpersonation(sid string) (imp *Impersonation, err error) {
+	fields, err := rep.HGetAll(wrapImpersonation(sid)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, redis.Nil
+	}
+	return &Impersonation{
+		UserName: fields["username"],
+		Actor:    fields["actor"],
+		Token:    fields["token"],
+	}, nil
+}
+
+// DelImpersonation 结束模拟登录
+func (rep *MyRedis) DelImpersonation(sid string) (err error) {
+	err = rep.Del(wrapImpersonation(sid)).Err()
+	return
+}
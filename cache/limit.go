@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+func wrapLimit(key string) string {
+	return fmt.Sprintf("limit:%s", key)
+}
+
+// Cooldown 冷却限制，interval内同一个key只允许通过一次
+func (rep *MyRedis) Cooldown(key string, interval time.Duration) (ok bool, err error) {
+	ok, err = rep.SetNX(wrapLimit(key), 1, interval).Result()
+	return
+}
+
+// IncrLimit 累加window内的调用次数，计数在窗口期后自动清零
+func (rep *MyRedis) IncrLimit(key string, window time.Duration) (count int64, err error) {
+	count, err = rep.Incr(wrapLimit(key)).Result()
+	if err != nil {
+		return
+	}
+	if count == 1 {
+		err = rep.Expire(wrapLimit(key), window).Err()
+	}
+	return
+}
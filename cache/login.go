@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoginUserSubject 按用户名统计登录失败
+func LoginUserSubject(username string) string {
+	return fmt.Sprintf("user:%s", username)
+}
+
+// LoginIPSubject 按IP统计登录失败
+func LoginIPSubject(ip string) string {
+	return fmt.Sprintf("ip:%s", ip)
+}
+
+func wrapLoginFail(subject string) string {
+	return fmt.Sprintf("login_fail:%s", subject)
+}
+
+func wrapLoginWait(subject string) string {
+	return fmt.Sprintf("login_wait:%s", subject)
+}
+
+// IncrLoginFail 增加登录失败次数，计数在窗口期后自动清零
+func (rep *MyRedis) IncrLoginFail(subject string, window time.Duration) (count int64, err error) {
+	count, err = rep.Incr(wrapLoginFail(subject)).Result()
+	if err != nil {
+		return
+	}
+	if count == 1 {
+		err = rep.Expire(wrapLoginFail(subject), window).Err()
+	}
+	return
+}
+
+// GetLoginFail 获取登录失败次数
+func (rep *MyRedis) GetLoginFail(subject string) (count int64, err error) {
+	count, err = rep.Get(wrapLoginFail(subject)).Int64()
+	if err == Nil {
+		return 0, nil
+	}
+	return
+}
+
+// SetLoginWait 在指定时长内禁止登录
+func (rep *MyRedis) SetLoginWait(subject string, wait time.Duration) (err error) {
+	err = rep.Set(wrapLoginWait(subject), 1, wait).Err()
+	return
+}
+
+// GetLoginWait 获取剩余禁止登录时长，未被禁止时返回0
+func (rep *MyRedis) GetLoginWait(subject string) (wait time.Duration, err error) {
+	wait, err = rep.PTTL(wrapLoginWait(subject)).Result()
+	if wait < 0 {
+		wait = 0
+	}
+	return
+}
+
+// ClearLoginFail 清除失败次数及登录限制
+func (rep *MyRedis) ClearLoginFail(subject string) (err error) {
+	err = rep.Del(wrapLoginFail(subject), wrapLoginWait(subject)).Err()
+	return
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// MfaLogin 已通过密码校验、等待两步验证的登录
+type MfaLogin struct {
+	// 用户名
+	UserName string
+	// 设备名称
+	Device string
+	// 客户端UA
+	UserAgent string
+	// 登录IP
+	IP string
+}
+
+func wrapMfaPending(username string) string {
+	return fmt.Sprintf("mfa_pending:%s", username)
+}
+
+func wrapMfaLogin(hash string) string {
+	return fmt.Sprintf("mfa_login:%s", hash)
+}
+
+func wrapTOTPUsed(username string, counter int64) string {
+	return fmt.Sprintf("totp_used:%s:%d", username, counter)
+}
+
+// SetMfaPending 存储绑定中尚未确认的加密密钥
+func (rep *MyRedis) SetMfaPending(username, secret string, expire time.Duration) (err error) {
+	err = rep.Set(wrapMfaPending(username), secret, expire).Err()
+	return
+}
+
+// GetMfaPending 获取绑定中的加密密钥
+func (rep *MyRedis) GetMfaPending(username string) (secret string, err error) {
+	secret, err = rep.Get(wrapMfaPending(username)).Result()
+	return
+}
+
+// DelMfaPending 删除绑定中的密钥
+func (rep *MyRedis) DelMfaPending(username string) (err error) {
+	err = rep.Del(wrapMfaPending(username)).Err()
+	return
+}
+
+// SetMfaLogin 存储等待两步验证的登录
+func (rep *MyRedis) SetMfaLogin(hash string, login *MfaLogin, expire time.Duration) (err error) {
+	_, err = rep.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HMSet(wrapMfaLogin(hash), map[string]interface{}{
+			"username":   login.UserName,
+			"device":     login.Device,
+			"user_agent": login.UserAgent,
+			"ip":         login.IP,
+			"attempts":   0,
+		})
+		pipe.Expire(wrapMfaLogin(hash), expire)
+		return nil
+	})
+	return
+}
+
+// GetMfaLogin 获取等待两步验证的登录并累加尝试次数，不存在时返回redis.Nil
+func (rep *MyRedis) GetMfaLogin(hash string) (login *MfaLogin, attempts int64, err error) {
+	fields, err := rep.HGetAll(wrapMfaLogin(hash)).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(fields) == 0 {
+		return nil, 0, redis.Nil
+	}
+	attempts, err = rep.HIncrBy(wrapMfaLogin(hash), "attempts", 1).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	login = &MfaLogin{
+		UserName:  fields["username"],
+		Device:    fields["device"],
+		UserAgent: fields["user_agent"],
+		IP:        fields["ip"],
+	}
+	return
+}
+
+// DelMfaLogin 删除等待两步验证的登录
+func (rep *MyRedis) DelMfaLogin(hash string) (err error) {
+	err = rep.Del(wrapMfaLogin(hash)).Err()
+	return
+}
+
+// UseTOTPCounter 记录已使用的验证码时间步，同一验证码重复使用时返回false
+func (rep *MyRedis) UseTOTPCounter(username string, counter int64, expire time.Duration) (ok bool, err error) {
+	ok, err = rep.SetNX(wrapTOTPUsed(username, counter), 1, expire).Result()
+	return
+}
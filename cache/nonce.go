@@ -0,0 +1,16 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+func wrapNonce(appKey, nonce string) string {
+	return fmt.Sprintf("open_nonce:%s:%s", appKey, nonce)
+}
+
+// UseNonce 记录请求随机数，重复使用时返回false
+func (rep *MyRedis) UseNonce(appKey, nonce string, expire time.Duration) (ok bool, err error) {
+	ok, err = rep.SetNX(wrapNonce(appKey, nonce), 1, expire).Result()
+	return
+}
@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// OAuthCode OAuth2授权码
+type OAuthCode struct {
+	// 客户端编号
+	ClientID string
+	// 用户名
+	UserName string
+	// 回调地址
+	RedirectURI string
+	// 授权范围
+	Scope string
+	// PKCE挑战码
+	CodeChallenge string
+	// PKCE挑战方式
+	CodeChallengeMethod string
+}
+
+// OAuthToken OAuth2访问Token或刷新Token
+type OAuthToken struct {
+	// 客户端编号
+	ClientID string
+	// 用户名，客户端凭证模式为空
+	UserName string
+	// 授权范围
+	Scope string
+	// access_token或refresh_token
+	Type string
+	// 配对的Token摘要，撤销时一并撤销
+	Pair string
+	// 过期时间(秒)
+	ExpireAt int64
+}
+
+func wrapOAuthCode(hash string) string {
+	return fmt.Sprintf("oauth_code:%s", hash)
+}
+
+func wrapOAuthToken(hash string) string {
+	return fmt.Sprintf("oauth_token:%s", hash)
+}
+
+func wrapUserOAuthTokens(username string) string {
+	return fmt.Sprintf("oauth_user_tokens:%s", username)
+}
+
+// indexTokenScript 将Token加入用户的Token索引，分数为过期时间，清理已过期的Token后按最晚的过期时间设置索引有效期
+var indexTokenScript = redis.NewScript(`
+redis.call("ZADD", KEYS[1], ARGV[1], ARGV[2])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[3])
+local last = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+redis.call("EXPIREAT", KEYS[1], last[2])
+return 1
+`)
+
+// SetOAuthCode 存储授权码
+func (rep *MyRedis) SetOAuthCode(hash string, code *OAuthCode, expire time.Duration) (err error) {
+	_, err = rep.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HMSet(wrapOAuthCode(hash), map[string]interface{}{
+			"client_id":             code.ClientID,
+			"username":              code.UserName,
+			"redirect_uri":          code.RedirectURI,
+			"scope":                 code.Scope,
+			"code_challenge":        code.CodeChallenge,
+			"code_challenge_method": code.CodeChallengeMethod,
+		})
+		pipe.Expire(wrapOAuthCode(hash), expire)
+		return nil
+	})
+	return
+}
+
+// TakeOAuthCode 取出并删除授权码，保证只能使用一次，不存在时返回redis.Nil
+func (rep *MyRedis) TakeOAuthCode(hash string) (*OAuthCode, error) {
+	var get *redis.StringStringMapCmd
+	_, err := rep.TxPipelined(func(pipe redis.Pipeliner) error {
+		get = pipe.HGetAll(wrapOAuthCode(hash))
+		pipe.Del(wrapOAuthCode(hash))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	fields := get.Val()
+	if len(fields) == 0 {
+		return nil, redis.Nil
+	}
+	return &OAuthCode{
+		ClientID:            fields["client_id"],
+		UserName:            fields["username"],
+		RedirectURI:         fields["redirect_uri"],
+		Scope:               fields["scope"],
+		CodeChallenge:       fields["code_challenge"],
+		CodeChallengeMethod: fields["code_challenge_method"],
+	}, nil
+}
+
+// SetOAuthToken 存储Token
+func (rep *MyRedis) SetOAuthToken(hash string, token *OAuthToken, expire time.Duration) (err error) {
+	_, err = rep.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HMSet(wrapOAuthToken(hash), map[string]interface{}{
+			"client_id": token.ClientID,
+			"username":  token.UserName,
+			"scope":     token.Scope,
+			"type":      token.Type,
+			"pair":      token.Pair,
+			"expire_at": token.ExpireAt,
+		})
+		pipe.Expire(wrapOAuthToken(hash), expire)
+		return nil
+	})
+	if err != nil || token.UserName == "" {
+		return
+	}
+	// 记录用户的全部Token，用户注销时一并撤销
+	err = indexTokenScript.Run(rep.Client, []string{wrapUserOAuthTokens(token.UserName)},
+		token.ExpireAt, hash, time.Now().Unix(),
+	).Err()
+	return
+}
+
+// GetOAuthToken 查询Token，不存在或已过期时返回redis.Nil
+func (rep *MyRedis) GetOAuthToken(hash string) (*OAuthToken, error) {
+	fields, err := rep.HGetAll(wrapOAuthToken(hash)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, redis.Nil
+	}
+	expireAt, _ := strconv.ParseInt(fields["expire_at"], 10, 64)
+	return &OAuthToken{
+		ClientID: fields["client_id"],
+		UserName: fields["username"],
+		Scope:    fields["scope"],
+		Type:     fields["type"],
+		Pair:     fields["pair"],
+		ExpireAt: expireAt,
+	}, nil
+}
+
+// DelOAuthToken 撤销Token
+func (rep *MyRedis) DelOAuthToken(hashes ...string) (err error) {
+	keys := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		if hash != "" {
+			keys = append(keys, wrapOAuthToken(hash))
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	err = rep.Del(keys...).Err()
+	return
+}
+
+// DelUserOAuthTokens 撤销用户的全部Token
+func (rep *MyRedis) DelUserOAuthTokens(username string) (err error) {
+	hashes, err := rep.ZRange(wrapUserOAuthTokens(username), 0, -1).Result()
+	if err != nil {
+		return
+	}
+	keys := []string{wrapUserOAuthTokens(username)}
+	for _, hash := range hashes {
+		keys = append(keys, wrapOAuthToken(hash))
+	}
+	err = rep.Del(keys...).Err()
+	return
+}
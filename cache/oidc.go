@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// OidcState 跳转到外部身份提供方前保存的登录状态
+type OidcState struct {
+	// 身份提供方名称
+	Provider string
+	// ID Token中需回传的随机数
+	Nonce string
+	// PKCE原始校验码
+	Verifier string
+	// 绑定模式下的当前用户名，登录模式为空
+	LinkUser string
+	// 设备名称
+	Device string
+	// 是否恢复注销宽限期内的账号
+	Restore bool
+}
+
+func wrapOidcState(hash string) string {
+	return fmt.Sprintf("oidc_state:%s", hash)
+}
+
+// SetOidcState 存储登录状态
+func (rep *MyRedis) SetOidcState(hash string, state *OidcState, expire time.Duration) (err error) {
+	_, err = rep.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HMSet(wrapOidcState(hash), map[string]interface{}{
+			"provider":  state.Provider,
+			"nonce":     state.Nonce,
+			"verifier":  state.Verifier,
+			"link_user": state.LinkUser,
+			"device":    state.Device,
+			"restore":   state.Restore,
+		})
+		pipe.Expire(wrapOidcState(hash), expire)
+		return nil
+	})
+	return
+}
+
+// TakeOidcState 取出并删除登录状态，不存在时返回redis.Nil
+func (rep *MyRedis) TakeOidcState(hash string) (*OidcState, error) {
+	var get *redis.StringStringMapCmd
+	_, err := rep.TxPipelined(func(pipe redis.Pipeliner) error {
+		get = pipe.HGetAll(wrapOidcState(hash))
+		pipe.Del(wrapOidcState(hash))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	fields := get.Val()
+	if len(fields) == 0 {
+		return nil, redis.Nil
+	}
+	return &OidcState{
+		Provider: fields["provider"],
+		Nonce:    fields["nonce"],
+		Verifier: fields["verifier"],
+		LinkUser: fields["link_user"],
+		Device:   fields["device"],
+		Restore:  fields["restore"] == "1",
+	}, nil
+}
@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+func wrapPasswordReset(hash string) string {
+	return fmt.Sprintf("password_reset:%s", hash)
+}
+
+// SetResetToken 存储重置密码Token
+func (rep *MyRedis) SetResetToken(hash, username string, expire time.Duration) (err error) {
+	err = rep.Set(wrapPasswordReset(hash), username, expire).Err()
+	return
+}
+
+// GetResetToken 查询重置密码Token对应的用户名，不删除Token
+func (rep *MyRedis) GetResetToken(hash string) (username string, err error) {
+	username, err = rep.Get(wrapPasswordReset(hash)).Result()
+	return
+}
+
+// TakeResetToken 取出并删除重置密码Token，保证只能使用一次，不存在时返回redis.Nil
+func (rep *MyRedis) TakeResetToken(hash string) (username string, err error) {
+	var get *redis.StringCmd
+	_, err = rep.TxPipelined(func(pipe redis.Pipeliner) error {
+		get = pipe.Get(wrapPasswordReset(hash))
+		pipe.Del(wrapPasswordReset(hash))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return get.Val(), nil
+}
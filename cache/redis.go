@@ -10,6 +10,9 @@ import (
 
 var config = conf.GetConfig()
 
+// Nil 键不存在
+const Nil = redis.Nil
+
 // RedisClient Redis缓存客户端单例
 var RedisClient *redis.Client
 
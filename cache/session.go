@@ -0,0 +1,222 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Session 登录会话，每台设备登录对应一个会话
+type Session struct {
+	// 会话编号
+	ID string
+	// 用户名
+	UserName string
+	// 设备名称
+	Device string
+	// 客户端UA
+	UserAgent string
+	// 登录IP
+	IP string
+	// 当前访问Token
+	Token string
+	// 当前刷新Token摘要
+	Refresh string
+	// 创建时间(毫秒)
+	CreatedAt int64
+	// 最后活跃时间(毫秒)
+	LastSeen int64
+}
+
+func wrapSession(sid string) string {
+	return fmt.Sprintf("session:%s", sid)
+}
+
+func wrapUserSessions(username string) string {
+	return fmt.Sprintf("user_sessions:%s", username)
+}
+
+func wrapRefresh(hash string) string {
+	return fmt.Sprintf("refresh:%s", hash)
+}
+
+// rotateScript 仅当会话当前的刷新Token与旧Token一致时才轮换，保证并发刷新时只有一次成功
+var rotateScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "refresh") ~= ARGV[1] then
+	return 0
+end
+redis.call("HMSET", KEYS[1], "refresh", ARGV[2], "token", ARGV[3])
+redis.call("PEXPIRE", KEYS[1], ARGV[6])
+redis.call("HMSET", KEYS[2], "username", ARGV[4], "session", ARGV[5])
+redis.call("PEXPIRE", KEYS[2], ARGV[6])
+return 1
+`)
+
+func (s *Session) fields() map[string]interface{} {
+	return map[string]interface{}{
+		"username":   s.UserName,
+		"device":     s.Device,
+		"user_agent": s.UserAgent,
+		"ip":         s.IP,
+		"token":      s.Token,
+		"refresh":    s.Refresh,
+		"created_at": s.CreatedAt,
+		"last_seen":  s.LastSeen,
+	}
+}
+
+func parseSession(sid string, fields map[string]string) *Session {
+	createdAt, _ := strconv.ParseInt(fields["created_at"], 10, 64)
+	lastSeen, _ := strconv.ParseInt(fields["last_seen"], 10, 64)
+	return &Session{
+		ID:        sid,
+		UserName:  fields["username"],
+		Device:    fields["device"],
+		UserAgent: fields["user_agent"],
+		IP:        fields["ip"],
+		Token:     fields["token"],
+		Refresh:   fields["refresh"],
+		CreatedAt: createdAt,
+		LastSeen:  lastSeen,
+	}
+}
+
+// CreateSession 创建会话，超出最大会话数时踢掉最早登录的会话
+func (rep *MyRedis) CreateSession(session *Session, expire time.Duration, maxSessions int) (err error) {
+	_, err = rep.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HMSet(wrapSession(session.ID), session.fields())
+		pipe.Expire(wrapSession(session.ID), expire)
+		pipe.HMSet(wrapRefresh(session.Refresh), map[string]interface{}{
+			"username": session.UserName,
+			"session":  session.ID,
+		})
+		pipe.Expire(wrapRefresh(session.Refresh), expire)
+		pipe.ZAdd(wrapUserSessions(session.UserName), redis.Z{
+			Score:  float64(session.CreatedAt),
+			Member: session.ID,
+		})
+		pipe.Expire(wrapUserSessions(session.UserName), expire)
+		return nil
+	})
+	if err != nil || maxSessions <= 0 {
+		return
+	}
+
+	// 清理已过期的会话后再统计
+	if _, err = rep.ListSessions(session.UserName); err != nil {
+		return
+	}
+	count, err := rep.ZCard(wrapUserSessions(session.UserName)).Result()
+	if err != nil || count <= int64(maxSessions) {
+		return
+	}
+	oldest, err := rep.ZRange(wrapUserSessions(session.UserName), 0, count-int64(maxSessions)-1).Result()
+	if err != nil {
+		return
+	}
+	for _, sid := range oldest {
+		if err = rep.DelSession(session.UserName, sid); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// GetSession 查询会话，不存在时返回redis.Nil
+func (rep *MyRedis) GetSession(sid string) (*Session, error) {
+	fields, err := rep.HGetAll(wrapSession(sid)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, redis.Nil
+	}
+	return parseSession(sid, fields), nil
+}
+
+// ListSessions 列出用户全部有效会话，按登录时间排序
+func (rep *MyRedis) ListSessions(username string) (sessions []*Session, err error) {
+	sids, err := rep.ZRange(wrapUserSessions(username), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, sid := range sids {
+		session, err := rep.GetSession(sid)
+		if err == redis.Nil {
+			// 会话已过期，顺便清理索引
+			rep.ZRem(wrapUserSessions(username), sid)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return
+}
+
+// 会话存在时才更新，避免过期或已删除的会话被重建为不过期的残缺键
+var touchScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], "last_seen", ARGV[1])
+return 1
+`)
+
+// TouchSession 更新会话最后活跃时间
+func (rep *MyRedis) TouchSession(sid string) (err error) {
+	err = touchScript.Run(rep.Client, []string{wrapSession(sid)}, time.Now().UnixMilli()).Err()
+	return
+}
+
+// DelSession 删除会话，使该设备的访问Token和刷新Token立即失效
+func (rep *MyRedis) DelSession(username, sid string) (err error) {
+	_, err = rep.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(wrapSession(sid))
+		pipe.ZRem(wrapUserSessions(username), sid)
+		return nil
+	})
+	return
+}
+
+// DelUserSessions 删除用户全部会话
+func (rep *MyRedis) DelUserSessions(username string) (err error) {
+	sids, err := rep.ZRange(wrapUserSessions(username), 0, -1).Result()
+	if err != nil {
+		return
+	}
+	keys := []string{wrapUserSessions(username)}
+	for _, sid := range sids {
+		keys = append(keys, wrapSession(sid))
+	}
+	err = rep.Del(keys...).Err()
+	return
+}
+
+// GetRefreshToken 查询刷新Token所属用户及会话，不存在时返回redis.Nil
+func (rep *MyRedis) GetRefreshToken(hash string) (username, sid string, err error) {
+	fields, err := rep.HGetAll(wrapRefresh(hash)).Result()
+	if err != nil {
+		return "", "", err
+	}
+	if len(fields) == 0 {
+		return "", "", redis.Nil
+	}
+	return fields["username"], fields["session"], nil
+}
+
+// RotateRefreshToken 轮换会话的刷新Token及访问Token，旧Token已不是当前有效Token时返回false
+func (rep *MyRedis) RotateRefreshToken(username, sid, oldHash, newHash, token string, expire time.Duration) (ok bool, err error) {
+	res, err := rotateScript.Run(rep.Client,
+		[]string{wrapSession(sid), wrapRefresh(newHash)},
+		oldHash, newHash, token, username, sid, expire.Milliseconds(),
+	).Int()
+	if err != nil {
+		return false, err
+	}
+	_ = rep.Expire(wrapUserSessions(username), expire).Err()
+	return res == 1, nil
+}
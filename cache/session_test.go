@@ -0,0 +1,36 @@
+package cache_test
+
+import (
+	"singo/cache"
+	"singo/testutil"
+	"testing"
+	"time"
+)
+
+func TestTouchExpiredSession(t *testing.T) {
+	server := testutil.SetupRedis(t)
+
+	// 会话过期或已删除后不应被重建
+	if err := cache.GetRedisClient().TouchSession("missing"); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists("session:missing") {
+		t.Error("过期会话被重建")
+	}
+}
+
+func TestTouchSession(t *testing.T) {
+	server := testutil.SetupRedis(t)
+	server.HSet("session:s1", "username", "alice01", "last_seen", "0")
+	server.SetTTL("session:s1", time.Hour)
+
+	if err := cache.GetRedisClient().TouchSession("s1"); err != nil {
+		t.Fatal(err)
+	}
+	if server.HGet("session:s1", "last_seen") == "0" {
+		t.Error("最后活跃时间未更新")
+	}
+	if server.TTL("session:s1") != time.Hour {
+		t.Errorf("会话有效期 = %v", server.TTL("session:s1"))
+	}
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+func wrapSmsCode(phone string) string {
+	return fmt.Sprintf("sms_code:%s", phone)
+}
+
+// SetSmsCode 存储短信验证码摘要，重新发送时覆盖旧验证码并重置尝试次数
+func (rep *MyRedis) SetSmsCode(phone, hash string, expire time.Duration) (err error) {
+	_, err = rep.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HMSet(wrapSmsCode(phone), map[string]interface{}{
+			"code":     hash,
+			"attempts": 0,
+		})
+		pipe.Expire(wrapSmsCode(phone), expire)
+		return nil
+	})
+	return
+}
+
+// 校验短信验证码：键不存在时不会重建，累加尝试次数，超过次数或校验成功时删除验证码
+var checkSmsCodeScript = redis.NewScript(`
+local code = redis.call("HGET", KEYS[1], "code")
+if not code then
+	return -1
+end
+local attempts = redis.call("HINCRBY", KEYS[1], "attempts", 1)
+if attempts > tonumber(ARGV[2]) then
+	redis.call("DEL", KEYS[1])
+	return 0
+end
+if code ~= ARGV[1] then
+	return 0
+end
+redis.call("DEL", KEYS[1])
+return 1
+`)
+
+// CheckSmsCode 原子地校验并消费短信验证码，校验成功返回true，验证码不存在时返回redis.Nil
+func (rep *MyRedis) CheckSmsCode(phone, hash string, maxAttempts int64) (ok bool, err error) {
+	res, err := checkSmsCodeScript.Run(rep.Client, []string{wrapSmsCode(phone)}, hash, maxAttempts).Int()
+	if err != nil {
+		return false, err
+	}
+	if res == -1 {
+		return false, redis.Nil
+	}
+	return res == 1, nil
+}
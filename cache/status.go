@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+func wrapSuspended(username string) string {
+	return fmt.Sprintf("suspended:%s", username)
+}
+
+// SetSuspended 标记用户已被封禁，expire为0表示永久
+func (rep *MyRedis) SetSuspended(username string, expire time.Duration) (err error) {
+	err = rep.Set(wrapSuspended(username), 1, expire).Err()
+	return
+}
+
+// IsSuspended 判断用户是否处于封禁中
+func (rep *MyRedis) IsSuspended(username string) (suspended bool, err error) {
+	count, err := rep.Exists(wrapSuspended(username)).Result()
+	return count > 0, err
+}
+
+// DelSuspended 解除封禁标记
+func (rep *MyRedis) DelSuspended(username string) (err error) {
+	err = rep.Del(wrapSuspended(username)).Err()
+	return
+}
@@ -0,0 +1,86 @@
+package captcha
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"image"
+	"image/png"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// 验证码类型
+const (
+	TypeImage  = "image"
+	TypeSlider = "slider"
+)
+
+// Challenge 生成的验证码，Answer需保存在服务端，其余内容返回给客户端
+type Challenge struct {
+	Type   string
+	Answer string
+	// 验证码图片，滑块验证码为带缺口的背景图
+	Image []byte
+	// 滑块图片
+	Piece []byte
+	// 滑块所在纵坐标
+	PieceY int
+}
+
+// New 生成指定类型的验证码，length为图片验证码位数
+func New(kind string, length int) (*Challenge, error) {
+	switch kind {
+	case TypeSlider:
+		return newSlider()
+	default:
+		return newDigits(length)
+	}
+}
+
+// Verify 校验客户端提交的答案，stored为New生成时保存的"类型:答案"
+// 滑块验证码允许tolerance像素的误差
+func Verify(stored, answer string, tolerance int) bool {
+	kind, expected, ok := strings.Cut(stored, ":")
+	if !ok || answer == "" {
+		return false
+	}
+	switch kind {
+	case TypeSlider:
+		x, err := strconv.Atoi(expected)
+		if err != nil {
+			return false
+		}
+		got, err := strconv.Atoi(strings.TrimSpace(answer))
+		if err != nil {
+			return false
+		}
+		return got >= x-tolerance && got <= x+tolerance
+	default:
+		return strings.EqualFold(strings.TrimSpace(answer), expected)
+	}
+}
+
+// Stored 返回需要保存在服务端的"类型:答案"
+func (c *Challenge) Stored() string {
+	return fmt.Sprintf("%s:%s", c.Type, c.Answer)
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// randIntn 使用crypto/rand生成[0,n)内的随机数，保证答案及图形位置不可预测
+func randIntn(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		// 系统随机源不可用时无法安全生成验证码
+		panic(err)
+	}
+	return int(v.Int64())
+}
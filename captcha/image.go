@@ -0,0 +1,124 @@
+package captcha
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+const (
+	imageWidth  = 120
+	imageHeight = 40
+	// 字形放大倍数
+	glyphScale = 3
+)
+
+// digitFont 5x7点阵数字字形
+var digitFont = [10][7]string{
+	{"01110", "10001", "10011", "10101", "11001", "10001", "01110"},
+	{"00100", "01100", "00100", "00100", "00100", "00100", "01110"},
+	{"01110", "10001", "00001", "00010", "00100", "01000", "11111"},
+	{"11110", "00001", "00001", "01110", "00001", "00001", "11110"},
+	{"00010", "00110", "01010", "10010", "11111", "00010", "00010"},
+	{"11111", "10000", "11110", "00001", "00001", "10001", "01110"},
+	{"00110", "01000", "10000", "11110", "10001", "10001", "01110"},
+	{"11111", "00001", "00010", "00100", "01000", "01000", "01000"},
+	{"01110", "10001", "10001", "01110", "10001", "10001", "01110"},
+	{"01110", "10001", "10001", "01111", "00001", "00010", "01100"},
+}
+
+// newDigits 生成数字图片验证码，字符随机偏移、倾斜并叠加干扰线和噪点
+func newDigits(length int) (*Challenge, error) {
+	if length <= 0 || length > 6 {
+		length = 4
+	}
+	img := image.NewRGBA(image.Rect(0, 0, imageWidth, imageHeight))
+	fill(img, color.RGBA{R: uint8(220 + randIntn(36)), G: uint8(220 + randIntn(36)), B: uint8(220 + randIntn(36)), A: 255})
+
+	var answer strings.Builder
+	step := imageWidth / length
+	for i := 0; i < length; i++ {
+		d := randIntn(10)
+		answer.WriteString(strconv.Itoa(d))
+		x := i*step + randIntn(step-5*glyphScale+1)
+		y := randIntn(imageHeight - 7*glyphScale + 1)
+		drawDigit(img, d, x, y, randIntn(5)-2, randomDark())
+	}
+
+	for i := 0; i < 4; i++ {
+		drawLine(img, randIntn(imageWidth), randIntn(imageHeight), randIntn(imageWidth), randIntn(imageHeight), randomDark())
+	}
+	for i := 0; i < imageWidth*imageHeight/20; i++ {
+		img.Set(randIntn(imageWidth), randIntn(imageHeight), randomDark())
+	}
+
+	data, err := encodePNG(img)
+	if err != nil {
+		return nil, err
+	}
+	return &Challenge{Type: TypeImage, Answer: answer.String(), Image: data}, nil
+}
+
+// drawDigit 绘制放大的点阵数字，shear为每行的水平偏移量用于倾斜
+func drawDigit(img *image.RGBA, d, x, y, shear int, c color.Color) {
+	for row, line := range digitFont[d] {
+		offset := (3 - row) * shear / 3
+		for col, bit := range line {
+			if bit != '1' {
+				continue
+			}
+			for dy := 0; dy < glyphScale; dy++ {
+				for dx := 0; dx < glyphScale; dx++ {
+					img.Set(x+col*glyphScale+dx+offset, y+row*glyphScale+dy, c)
+				}
+			}
+		}
+	}
+}
+
+// drawLine 使用Bresenham算法绘制干扰线
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x0 += sx
+		} else {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func fill(img *image.RGBA, c color.RGBA) {
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+func randomDark() color.RGBA {
+	return color.RGBA{R: uint8(randIntn(120)), G: uint8(randIntn(120)), B: uint8(randIntn(120)), A: 255}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
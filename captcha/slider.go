@@ -0,0 +1,179 @@
+package captcha
+
+import (
+	"crypto/rand"
+	"image"
+	"image/color"
+	"strconv"
+)
+
+const (
+	sliderWidth  = 280
+	sliderHeight = 160
+	pieceSize    = 50
+	// 干扰缺口数量
+	decoyGaps = 2
+	// 缺口阴影边缘渐变的宽度
+	gapFade = 6
+	// 全图噪点幅度
+	noiseLevel = 12
+)
+
+// newSlider 生成滑块验证码，答案为缺口的横坐标
+func newSlider() (*Challenge, error) {
+	bg := image.NewRGBA(image.Rect(0, 0, sliderWidth, sliderHeight))
+	drawBackground(bg)
+
+	// 缺口不与左侧滑块初始位置重叠
+	x, y := gapPosition()
+
+	piece := image.NewRGBA(image.Rect(0, 0, pieceSize, pieceSize))
+	for py := 0; py < pieceSize; py++ {
+		for px := 0; px < pieceSize; px++ {
+			c := bg.RGBAAt(x+px, y+py)
+			if px == 0 || py == 0 || px == pieceSize-1 || py == pieceSize-1 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			piece.SetRGBA(px, py, c)
+		}
+	}
+
+	// 同一行绘制与真实缺口外观相同的干扰缺口，需比对滑块内容才能区分
+	gaps := []int{x}
+	for i := 0; i < decoyGaps*10 && len(gaps) <= decoyGaps; i++ {
+		dx, _ := gapPosition()
+		if !overlaps(gaps, dx) {
+			gaps = append(gaps, dx)
+		}
+	}
+	for _, gx := range gaps {
+		drawGap(bg, gx, y)
+	}
+	// 滑块取自加噪前的背景，缺口与滑块不再保持固定的亮度比例
+	if err := addNoise(bg); err != nil {
+		return nil, err
+	}
+
+	bgData, err := encodePNG(bg)
+	if err != nil {
+		return nil, err
+	}
+	pieceData, err := encodePNG(piece)
+	if err != nil {
+		return nil, err
+	}
+	return &Challenge{
+		Type:   TypeSlider,
+		Answer: strconv.Itoa(x),
+		Image:  bgData,
+		Piece:  pieceData,
+		PieceY: y,
+	}, nil
+}
+
+// gapPosition 随机生成缺口位置
+func gapPosition() (int, int) {
+	return pieceSize + 10 + randIntn(sliderWidth-2*pieceSize-9), 10 + randIntn(sliderHeight-pieceSize-20)
+}
+
+// overlaps 判断缺口是否与已有缺口横向重叠
+func overlaps(gaps []int, x int) bool {
+	for _, gx := range gaps {
+		if abs(gx-x) < pieceSize {
+			return true
+		}
+	}
+	return false
+}
+
+// drawGap 绘制缺口阴影，深浅随机且边缘渐变，并叠加逐像素的扰动
+func drawGap(img *image.RGBA, x, y int) {
+	depth := 35 + randIntn(25)
+	for py := 0; py < pieceSize; py++ {
+		for px := 0; px < pieceSize; px++ {
+			// 到缺口边缘的最短距离
+			edge := px
+			for _, d := range []int{py, pieceSize - 1 - px, pieceSize - 1 - py} {
+				if d < edge {
+					edge = d
+				}
+			}
+			shade := depth
+			if edge < gapFade {
+				shade = depth * (edge + 1) / (gapFade + 1)
+			}
+			shade += randIntn(11) - 5
+			c := img.RGBAAt(x+px, y+py)
+			img.SetRGBA(x+px, y+py, color.RGBA{R: darken(c.R, shade), G: darken(c.G, shade), B: darken(c.B, shade), A: 255})
+		}
+	}
+}
+
+// addNoise 为整张背景叠加亮度噪点
+func addNoise(img *image.RGBA) error {
+	b := img.Bounds()
+	noise := make([]byte, b.Dx()*b.Dy())
+	if _, err := rand.Read(noise); err != nil {
+		return err
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			n := int(noise[(y-b.Min.Y)*b.Dx()+x-b.Min.X])%(2*noiseLevel+1) - noiseLevel
+			c := img.RGBAAt(x, y)
+			img.SetRGBA(x, y, color.RGBA{R: clamp(int(c.R) + n), G: clamp(int(c.G) + n), B: clamp(int(c.B) + n), A: 255})
+		}
+	}
+	return nil
+}
+
+// darken 按百分比降低亮度
+func darken(v uint8, percent int) uint8 {
+	return clamp(int(v) * (100 - percent) / 100)
+}
+
+func clamp(v int) uint8 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+// drawBackground 绘制随机渐变背景及色块，避免缺口位置可被简单识别
+func drawBackground(img *image.RGBA) {
+	from := randomLight()
+	to := randomLight()
+	for y := 0; y < sliderHeight; y++ {
+		for x := 0; x < sliderWidth; x++ {
+			t := float64(x+y) / float64(sliderWidth+sliderHeight)
+			img.SetRGBA(x, y, color.RGBA{
+				R: lerp(from.R, to.R, t),
+				G: lerp(from.G, to.G, t),
+				B: lerp(from.B, to.B, t),
+				A: 255,
+			})
+		}
+	}
+
+	for i := 0; i < 12; i++ {
+		cx, cy, r := randIntn(sliderWidth), randIntn(sliderHeight), 8+randIntn(30)
+		c := randomLight()
+		for y := cy - r; y <= cy+r; y++ {
+			for x := cx - r; x <= cx+r; x++ {
+				if (x-cx)*(x-cx)+(y-cy)*(y-cy) <= r*r && image.Pt(x, y).In(img.Bounds()) {
+					img.SetRGBA(x, y, c)
+				}
+			}
+		}
+	}
+}
+
+func randomLight() color.RGBA {
+	return color.RGBA{R: uint8(100 + randIntn(156)), G: uint8(100 + randIntn(156)), B: uint8(100 + randIntn(156)), A: 255}
+}
+
+func lerp(a, b uint8, t float64) uint8 {
+	return uint8(float64(a) + (float64(b)-float64(a))*t)
+}
@@ -5,17 +5,45 @@ import (
 	"github.com/spf13/viper"
 	"singo/logger"
 	"sync"
+	"time"
 )
 
 type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	Redis    RedisConfig
+	Jwt      JwtConfig
+	Login    LoginConfig
+	Mail     MailConfig
+	Mfa      MfaConfig
+	OAuth    OAuthConfig
+	Oidc     OidcConfig
+	Open     OpenConfig
+	Password PasswordConfig
+	Captcha  CaptchaConfig
+	Sms      SmsConfig
+	Storage  StorageConfig
+	Avatar   AvatarConfig
+	Account  AccountConfig
 }
 
 type ServerConfig struct {
 	Port   int    `mapstructure:"port"`
 	Secret string `mapstructure:"secret"`
+	// 敏感字段加密密钥
+	EncryptKey string `mapstructure:"encrypt_key"`
+	// 对外访问地址，用于生成邮件中的链接
+	BaseURL string `mapstructure:"base_url"`
+	// 访问Token有效期
+	AccessExpire time.Duration `mapstructure:"access_expire"`
+	// 刷新Token有效期
+	RefreshExpire time.Duration `mapstructure:"refresh_expire"`
+	// 每个用户最多同时在线的会话数，超出时踢掉最早登录的会话
+	MaxSessions int `mapstructure:"max_sessions"`
+	// 管理员模拟登录Token有效期
+	ImpersonateExpire time.Duration `mapstructure:"impersonate_expire"`
+	// 启动时自动授予管理员角色的用户名列表
+	Admins []string `mapstructure:"admins"`
 }
 
 type DatabaseConfig struct {
@@ -32,6 +60,212 @@ type RedisConfig struct {
 	Db       string `mapstructure:"db"`
 }
 
+type JwtConfig struct {
+	// 当前用于签名的密钥编号，为空时使用server.secret进行HS256签名
+	ActiveKid string `mapstructure:"active_kid"`
+	// 密钥列表，轮换期间旧密钥只需保留公钥用于验证
+	Keys []JwtKeyConfig `mapstructure:"keys"`
+}
+
+type JwtKeyConfig struct {
+	// 密钥编号，对应JWT头部的kid
+	Kid string `mapstructure:"kid"`
+	// PKCS8格式私钥PEM文件路径，支持RSA和Ed25519
+	Private string `mapstructure:"private"`
+	// PKIX格式公钥PEM文件路径，配置了私钥时可省略
+	Public string `mapstructure:"public"`
+}
+
+type LoginConfig struct {
+	// 失败计数的统计窗口
+	Window time.Duration `mapstructure:"window"`
+	// 不做延迟的失败次数
+	FreeAttempts int64 `mapstructure:"free_attempts"`
+	// 首次延迟时长，此后每次失败翻倍
+	BaseDelay time.Duration `mapstructure:"base_delay"`
+	// 最大延迟时长
+	MaxDelay time.Duration `mapstructure:"max_delay"`
+	// 同一账号失败多少次后锁定
+	MaxAttempts int64 `mapstructure:"max_attempts"`
+	// 同一IP失败多少次后锁定
+	IPMaxAttempts int64 `mapstructure:"ip_max_attempts"`
+	// 锁定时长
+	LockDuration time.Duration `mapstructure:"lock_duration"`
+	// 离线IP库文件，CSV格式：起始IP,结束IP,国家代码,省份,城市
+	GeoIPFile string `mapstructure:"geoip_file"`
+	// 新设备或新地区登录时是否邮件提醒
+	Notify bool `mapstructure:"notify"`
+}
+
+type MailConfig struct {
+	// 发送方式 smtp/console/file
+	Driver   string `mapstructure:"driver"`
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	// file方式写入的文件路径
+	File string `mapstructure:"file"`
+	// 验证链接有效期
+	VerifyExpire time.Duration `mapstructure:"verify_expire"`
+	// 前端重置密码页面地址
+	ResetURL string `mapstructure:"reset_url"`
+	// 重置密码链接有效期
+	ResetExpire time.Duration `mapstructure:"reset_expire"`
+	// 重发邮件的最小间隔
+	ResendInterval time.Duration `mapstructure:"resend_interval"`
+}
+
+type MfaConfig struct {
+	// 认证器中显示的发行方名称
+	Issuer string `mapstructure:"issuer"`
+	// 允许的时钟偏差(时间步数)
+	Skew int64 `mapstructure:"skew"`
+	// 两步验证登录的有效期
+	LoginExpire time.Duration `mapstructure:"login_expire"`
+	// 两步验证登录的最大尝试次数
+	MaxAttempts int64 `mapstructure:"max_attempts"`
+}
+
+type OAuthConfig struct {
+	// 授权码有效期
+	CodeExpire time.Duration `mapstructure:"code_expire"`
+	// 访问Token有效期
+	AccessExpire time.Duration `mapstructure:"access_expire"`
+	// 刷新Token有效期
+	RefreshExpire time.Duration `mapstructure:"refresh_expire"`
+}
+
+type OidcConfig struct {
+	// 外部身份提供方列表
+	Providers []OidcProviderConfig `mapstructure:"providers"`
+}
+
+type OidcProviderConfig struct {
+	// 名称，用于路由中区分身份提供方
+	Name string `mapstructure:"name"`
+	// 签发方地址，用于获取/.well-known/openid-configuration
+	Issuer       string `mapstructure:"issuer"`
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	// 回调地址，需在身份提供方处登记
+	RedirectURL string `mapstructure:"redirect_url"`
+	// 申请的授权范围，默认 openid profile email
+	Scopes []string `mapstructure:"scopes"`
+}
+
+type PasswordConfig struct {
+	// 密码摘要算法 bcrypt/argon2id，修改后旧摘要会在用户登录时自动升级
+	Algorithm string `mapstructure:"algorithm"`
+	// bcrypt计算难度
+	BcryptCost int          `mapstructure:"bcrypt_cost"`
+	Argon2     Argon2Config `mapstructure:"argon2"`
+	// 密码最小长度
+	MinLength int `mapstructure:"min_length"`
+	// 至少包含的字符种类(大写、小写、数字、特殊字符)
+	MinClasses int `mapstructure:"min_classes"`
+	// 同一字符最多连续出现次数，为0不限制
+	MaxRepeat int `mapstructure:"max_repeat"`
+	// 是否禁止密码包含用户名或昵称
+	DisallowUserInfo bool `mapstructure:"disallow_user_info"`
+	// 不能与最近多少次使用过的密码相同，为0不限制
+	History int `mapstructure:"history"`
+	// 离线泄露密码库目录，为空不检查
+	BreachedDir string `mapstructure:"breached_dir"`
+}
+
+type Argon2Config struct {
+	// 内存大小(KiB)
+	Memory uint32 `mapstructure:"memory"`
+	// 迭代次数
+	Iterations uint32 `mapstructure:"iterations"`
+	// 并行度
+	Parallelism uint8  `mapstructure:"parallelism"`
+	SaltLength  uint32 `mapstructure:"salt_length"`
+	KeyLength   uint32 `mapstructure:"key_length"`
+}
+
+type AccountConfig struct {
+	// 注销后保留数据的宽限期，到期后清除个人信息
+	DeleteGrace time.Duration `mapstructure:"delete_grace"`
+	// 清除任务的执行间隔
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
+type StorageConfig struct {
+	// 存储方式 local/s3
+	Driver string      `mapstructure:"driver"`
+	Local  LocalConfig `mapstructure:"local"`
+	S3     S3Config    `mapstructure:"s3"`
+}
+
+type LocalConfig struct {
+	// 存储目录
+	Dir string `mapstructure:"dir"`
+	// 对外访问地址前缀
+	BaseURL string `mapstructure:"base_url"`
+}
+
+type S3Config struct {
+	// 服务地址，如 https://s3.amazonaws.com 或 http://127.0.0.1:9000
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	// 是否使用 endpoint/bucket/key 格式的地址，MinIO需开启
+	PathStyle bool `mapstructure:"path_style"`
+	// 对外访问地址前缀，为空时使用对象地址
+	PublicURL string `mapstructure:"public_url"`
+}
+
+type AvatarConfig struct {
+	// 上传文件大小上限(字节)
+	MaxSize int64 `mapstructure:"max_size"`
+	// 图片最大边长
+	MaxDimension int `mapstructure:"max_dimension"`
+	// 缩略图边长，第一个作为头像地址
+	Sizes []int `mapstructure:"sizes"`
+}
+
+type SmsConfig struct {
+	// 发送方式 log/fake
+	Driver string `mapstructure:"driver"`
+	// 短信签名
+	Sign string `mapstructure:"sign"`
+	// 验证码有效期
+	CodeExpire time.Duration `mapstructure:"code_expire"`
+	// 验证码最多尝试次数
+	MaxAttempts int64 `mapstructure:"max_attempts"`
+	// 同一手机号发送的最小间隔
+	SendInterval time.Duration `mapstructure:"send_interval"`
+	// 同一手机号每天最多发送次数
+	PhoneDailyLimit int64 `mapstructure:"phone_daily_limit"`
+	// 同一IP每小时最多发送次数
+	IPHourlyLimit int64 `mapstructure:"ip_hourly_limit"`
+}
+
+type CaptchaConfig struct {
+	// 默认验证码类型 image/slider
+	Type string `mapstructure:"type"`
+	// 图片验证码位数
+	Length int `mapstructure:"length"`
+	// 验证码有效期
+	Expire time.Duration `mapstructure:"expire"`
+	// 滑块验证码允许的误差像素
+	Tolerance int `mapstructure:"tolerance"`
+	// 需要验证码的场景，如login、register
+	Routes []string `mapstructure:"routes"`
+	// 同一IP登录失败多少次后login场景才要求验证码，为0时始终要求，其他场景不受影响
+	FailThreshold int64 `mapstructure:"fail_threshold"`
+}
+
+type OpenConfig struct {
+	// 签名时间戳允许的最大偏差
+	TimestampWindow time.Duration `mapstructure:"timestamp_window"`
+}
+
 // 定义配置结构体
 var config *Config
 var configOnce sync.Once
@@ -46,8 +280,66 @@ func GetConfig() *Config {
 // Init 初始化配置项
 func loadConfig() *Config {
 	// 设置 Viper
-	viper.SetConfigFile("./config.yaml") // 替换为你的配置文件路径
-	viper.SetConfigType("yaml")          // 如果是 YAML 格式的配置文件，这里设置为 "yaml"
+	viper.SetConfigName("config") // 配置文件名
+	viper.SetConfigType("yaml")   // 如果是 YAML 格式的配置文件，这里设置为 "yaml"
+	viper.AddConfigPath(".")
+	// 运行子包测试时工作目录为包目录，从上级目录读取
+	viper.AddConfigPath("..")
+
+	// 默认值
+	viper.SetDefault("server.access_expire", "2h")
+	viper.SetDefault("server.refresh_expire", "720h")
+	viper.SetDefault("server.max_sessions", 5)
+	viper.SetDefault("server.impersonate_expire", "15m")
+	viper.SetDefault("mail.driver", "console")
+	viper.SetDefault("mail.verify_expire", "24h")
+	viper.SetDefault("mail.reset_expire", "30m")
+	viper.SetDefault("mail.resend_interval", "1m")
+	viper.SetDefault("mfa.issuer", "Gugo")
+	viper.SetDefault("mfa.skew", 1)
+	viper.SetDefault("mfa.login_expire", "5m")
+	viper.SetDefault("mfa.max_attempts", 5)
+	viper.SetDefault("oauth.code_expire", "10m")
+	viper.SetDefault("oauth.access_expire", "1h")
+	viper.SetDefault("oauth.refresh_expire", "720h")
+	viper.SetDefault("open.timestamp_window", "5m")
+	viper.SetDefault("account.delete_grace", "720h")
+	viper.SetDefault("account.purge_interval", "1h")
+	viper.SetDefault("storage.driver", "local")
+	viper.SetDefault("storage.local.dir", "./uploads")
+	viper.SetDefault("storage.local.base_url", "/uploads")
+	viper.SetDefault("avatar.max_size", 2<<20)
+	viper.SetDefault("avatar.max_dimension", 4096)
+	viper.SetDefault("avatar.sizes", []int{256, 64})
+	viper.SetDefault("sms.driver", "log")
+	viper.SetDefault("sms.code_expire", "5m")
+	viper.SetDefault("sms.max_attempts", 5)
+	viper.SetDefault("sms.send_interval", "1m")
+	viper.SetDefault("sms.phone_daily_limit", 10)
+	viper.SetDefault("sms.ip_hourly_limit", 20)
+	viper.SetDefault("captcha.type", "image")
+	viper.SetDefault("captcha.length", 4)
+	viper.SetDefault("captcha.expire", "5m")
+	viper.SetDefault("captcha.tolerance", 5)
+	viper.SetDefault("password.algorithm", "bcrypt")
+	viper.SetDefault("password.bcrypt_cost", 12)
+	viper.SetDefault("password.argon2.memory", 64*1024)
+	viper.SetDefault("password.argon2.iterations", 3)
+	viper.SetDefault("password.argon2.parallelism", 2)
+	viper.SetDefault("password.argon2.salt_length", 16)
+	viper.SetDefault("password.argon2.key_length", 32)
+	viper.SetDefault("password.min_length", 8)
+	viper.SetDefault("password.min_classes", 2)
+	viper.SetDefault("password.max_repeat", 3)
+	viper.SetDefault("password.disallow_user_info", true)
+	viper.SetDefault("password.history", 5)
+	viper.SetDefault("login.window", "15m")
+	viper.SetDefault("login.free_attempts", 3)
+	viper.SetDefault("login.base_delay", "1s")
+	viper.SetDefault("login.max_delay", "30s")
+	viper.SetDefault("login.max_attempts", 10)
+	viper.SetDefault("login.ip_max_attempts", 50)
+	viper.SetDefault("login.lock_duration", "30m")
 
 	// 读取配置文件
 	if err := viper.ReadInConfig(); err != nil {
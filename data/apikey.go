@@ -0,0 +1,45 @@
+package data
+
+import "singo/model"
+
+// @Description API Key序列化器
+type ApiKeyReq struct {
+	// 编号
+	ID uint `json:"id"`
+	// 名称
+	Name string `json:"name"`
+	// 前缀
+	Prefix string `json:"prefix"`
+	// 授权范围
+	Scopes []string `json:"scopes"`
+	// 过期时间
+	ExpiresAt int64 `json:"expires_at,omitempty"`
+	// 最后使用时间
+	LastUsedAt int64 `json:"last_used_at,omitempty"`
+	// 创建时间
+	CreatedAt int64 `json:"created_at"`
+	// 完整Key，仅创建时返回一次
+	Key string `json:"key,omitempty"`
+}
+
+// BuildApiKey 序列化API Key
+func BuildApiKey(key *model.ApiKey) *ApiKeyReq {
+	return &ApiKeyReq{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		ExpiresAt:  unixMilli(key.ExpiresAt),
+		LastUsedAt: unixMilli(key.LastUsedAt),
+		CreatedAt:  timeMilli(key.CreatedAt),
+	}
+}
+
+// BuildApiKeys 序列化API Key列表
+func BuildApiKeys(keys []*model.ApiKey) []*ApiKeyReq {
+	items := make([]*ApiKeyReq, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, BuildApiKey(key))
+	}
+	return items
+}
@@ -0,0 +1,45 @@
+package data
+
+import "singo/model"
+
+// @Description 开放平台应用序列化器
+type AppClientReq struct {
+	// 应用Key
+	AppKey string `json:"app_key"`
+	// 名称
+	Name string `json:"name"`
+	// 状态
+	Status string `json:"status"`
+	// 创建人
+	CreatedBy string `json:"created_by"`
+	// 创建时间
+	CreatedAt int64 `json:"created_at"`
+	// 修改人
+	UpdatedBy string `json:"updated_by"`
+	// 修改时间
+	UpdatedAt int64 `json:"updated_at"`
+	// 应用密钥，仅创建时返回一次
+	AppSecret string `json:"app_secret,omitempty"`
+}
+
+// BuildAppClient 序列化开放平台应用
+func BuildAppClient(app *model.AppClient) *AppClientReq {
+	return &AppClientReq{
+		AppKey:    app.AppKey,
+		Name:      app.Name,
+		Status:    app.Status,
+		CreatedBy: app.CreatedBy,
+		CreatedAt: timeMilli(app.CreatedAt),
+		UpdatedBy: app.UpdatedBy,
+		UpdatedAt: timeMilli(app.UpdatedAt),
+	}
+}
+
+// BuildAppClients 序列化开放平台应用列表
+func BuildAppClients(apps []*model.AppClient) []*AppClientReq {
+	items := make([]*AppClientReq, 0, len(apps))
+	for _, app := range apps {
+		items = append(items, BuildAppClient(app))
+	}
+	return items
+}
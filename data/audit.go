@@ -0,0 +1,38 @@
+package data
+
+import "singo/model"
+
+// @Description 审计日志序列化器
+type AuditLogReq struct {
+	// 编号
+	ID uint `json:"id"`
+	// 操作人
+	Actor string `json:"actor"`
+	// 操作类型
+	Action string `json:"action"`
+	// 操作对象
+	Target string `json:"target"`
+	// 详情
+	Detail string `json:"detail"`
+	// 操作IP
+	IP string `json:"ip"`
+	// 创建时间
+	CreatedAt int64 `json:"created_at"`
+}
+
+// BuildAuditLogs 序列化审计日志列表
+func BuildAuditLogs(logs []*model.AuditLog) []*AuditLogReq {
+	items := make([]*AuditLogReq, 0, len(logs))
+	for _, log := range logs {
+		items = append(items, &AuditLogReq{
+			ID:        log.ID,
+			Actor:     log.Actor,
+			Action:    log.Action,
+			Target:    log.Target,
+			Detail:    log.Detail,
+			IP:        log.IP,
+			CreatedAt: timeMilli(log.CreatedAt),
+		})
+	}
+	return items
+}
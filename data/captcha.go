@@ -0,0 +1,17 @@
+package data
+
+// @Description 验证码序列化器
+type CaptchaReq struct {
+	// 验证码编号
+	ID string `json:"id"`
+	// 验证码类型 image/slider
+	Type string `json:"type"`
+	// 验证码图片，滑块验证码为带缺口的背景图，data URI格式
+	Image string `json:"image"`
+	// 滑块图片，data URI格式
+	Piece string `json:"piece,omitempty"`
+	// 滑块所在纵坐标
+	PieceY int `json:"piece_y,omitempty"`
+	// 过期时间
+	ExpireAt int64 `json:"expire_at"`
+}
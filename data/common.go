@@ -1,5 +1,7 @@
 package data
 
+import "time"
+
 // @Description 基础序列化响应
 type Response struct {
 	// 业务处理状态
@@ -14,10 +16,12 @@ type Response struct {
 
 // @Description 分页结构体
 type Pagination struct {
-	// 总条数
-	Total int64 `json:"total"`
+	// 总条数，游标分页时不返回
+	Total *int64 `json:"total,omitempty"`
 	// 数据
 	Items interface{} `json:"items,omitempty"`
+	// 下一页游标，为空表示没有更多数据
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // 三位数错误编码为复用http原本含义
@@ -70,13 +74,25 @@ func NewPageResponse(total int64, array interface{}) *Response {
 	res := &Response{
 		Success: true,
 		Data: &Pagination{
-			Total: total,
+			Total: &total,
 			Items: array,
 		},
 	}
 	return res
 }
 
+// NewCursorResponse 游标分页参数处理
+func NewCursorResponse(next string, array interface{}) *Response {
+	res := &Response{
+		Success: true,
+		Data: &Pagination{
+			Items:      array,
+			NextCursor: next,
+		},
+	}
+	return res
+}
+
 // ParamErr 各种参数错误
 func ParamErr(msg string) *Response {
 	if msg == "" {
@@ -84,3 +100,19 @@ func ParamErr(msg string) *Response {
 	}
 	return NewErrorResponse(CodeParamErr, msg)
 }
+
+// unixMilli 可为空的时间转换为毫秒时间戳，为空时返回0
+func unixMilli(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return timeMilli(*t)
+}
+
+// timeMilli 时间转换为毫秒时间戳，零值(如迁移前写入、未记录时间的数据)返回0
+func timeMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
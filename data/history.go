@@ -0,0 +1,56 @@
+package data
+
+import "singo/model"
+
+// @Description 登录历史序列化器
+type LoginHistoryReq struct {
+	// 编号
+	ID uint `json:"id"`
+	// 是否成功
+	Success bool `json:"success"`
+	// 失败原因
+	Reason string `json:"reason,omitempty"`
+	// 登录IP
+	IP string `json:"ip"`
+	// 客户端UA
+	UserAgent string `json:"user_agent"`
+	// 设备名称
+	Device string `json:"device"`
+	// 浏览器
+	Browser string `json:"browser"`
+	// 操作系统
+	OS string `json:"os"`
+	// 设备类型
+	DeviceType string `json:"device_type"`
+	// 国家或地区代码
+	Country string `json:"country"`
+	// 省份
+	Region string `json:"region"`
+	// 城市
+	City string `json:"city"`
+	// 登录时间
+	CreatedAt int64 `json:"created_at"`
+}
+
+// BuildLoginHistories 序列化登录历史列表
+func BuildLoginHistories(histories []*model.LoginHistory) []*LoginHistoryReq {
+	items := make([]*LoginHistoryReq, 0, len(histories))
+	for _, h := range histories {
+		items = append(items, &LoginHistoryReq{
+			ID:         h.ID,
+			Success:    h.Success,
+			Reason:     h.Reason,
+			IP:         h.IP,
+			UserAgent:  h.UserAgent,
+			Device:     h.Device,
+			Browser:    h.Browser,
+			OS:         h.OS,
+			DeviceType: h.DeviceType,
+			Country:    h.Country,
+			Region:     h.Region,
+			City:       h.City,
+			CreatedAt:  timeMilli(h.CreatedAt),
+		})
+	}
+	return items
+}
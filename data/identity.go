@@ -0,0 +1,35 @@
+package data
+
+import "singo/model"
+
+// @Description 外部身份序列化器
+type IdentityReq struct {
+	// 编号
+	ID uint `json:"id"`
+	// 身份提供方名称
+	Provider string `json:"provider"`
+	// 邮箱
+	Email string `json:"email"`
+	// 绑定时间
+	CreatedAt int64 `json:"created_at"`
+}
+
+// BuildIdentities 序列化外部身份列表
+func BuildIdentities(identities []*model.Identity) []*IdentityReq {
+	items := make([]*IdentityReq, 0, len(identities))
+	for _, identity := range identities {
+		items = append(items, &IdentityReq{
+			ID:        identity.ID,
+			Provider:  identity.Provider,
+			Email:     identity.Email,
+			CreatedAt: timeMilli(identity.CreatedAt),
+		})
+	}
+	return items
+}
+
+// @Description 外部登录跳转地址
+type OidcRedirectReq struct {
+	// 身份提供方授权地址
+	AuthURL string `json:"auth_url"`
+}
@@ -0,0 +1,61 @@
+package data
+
+import "singo/model"
+
+// @Description 两步验证绑定信息
+type MfaSetupReq struct {
+	// 密钥，用于手动输入
+	Secret string `json:"secret"`
+	// otpauth地址，用于生成二维码
+	URI string `json:"uri"`
+}
+
+// @Description 等待两步验证的登录
+type MfaPendingReq struct {
+	// 需要两步验证
+	MfaRequired bool `json:"mfa_required"`
+	// 两步验证Token
+	MfaToken string `json:"mfa_token"`
+	// 过期时间
+	MfaExpire int64 `json:"mfa_expire"`
+}
+
+// @Description 两步验证恢复码
+type RecoveryCodesReq struct {
+	// 恢复码，仅展示一次
+	Codes []string `json:"codes"`
+}
+
+// @Description 两步验证状态，不包含密钥及恢复码本身
+type MfaInfoReq struct {
+	// 是否开启两步验证
+	Enabled bool `json:"enabled"`
+	// 恢复码使用情况
+	RecoveryCodes []*RecoveryCodeInfoReq `json:"recovery_codes"`
+}
+
+// @Description 恢复码使用情况
+type RecoveryCodeInfoReq struct {
+	// 编号
+	ID uint `json:"id"`
+	// 使用时间，未使用时为0
+	UsedAt int64 `json:"used_at"`
+	// 生成时间
+	CreatedAt int64 `json:"created_at"`
+}
+
+// BuildMfaInfo 序列化两步验证状态
+func BuildMfaInfo(user *model.User, codes []*model.RecoveryCode) *MfaInfoReq {
+	info := &MfaInfoReq{
+		Enabled:       user.MfaEnabled,
+		RecoveryCodes: make([]*RecoveryCodeInfoReq, 0, len(codes)),
+	}
+	for _, code := range codes {
+		info.RecoveryCodes = append(info.RecoveryCodes, &RecoveryCodeInfoReq{
+			ID:        code.ID,
+			UsedAt:    unixMilli(code.UsedAt),
+			CreatedAt: timeMilli(code.CreatedAt),
+		})
+	}
+	return info
+}
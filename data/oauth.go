@@ -0,0 +1,146 @@
+package data
+
+import (
+	"singo/model"
+	"strings"
+)
+
+// @Description OAuth2 Token响应
+type OAuthToken struct {
+	// 访问Token
+	AccessToken string `json:"access_token"`
+	// Token类型
+	TokenType string `json:"token_type"`
+	// 有效期(秒)
+	ExpiresIn int64 `json:"expires_in"`
+	// 刷新Token
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// 授权范围
+	Scope string `json:"scope,omitempty"`
+}
+
+// @Description OAuth2错误响应
+type OAuthError struct {
+	// 错误码
+	Error string `json:"error"`
+	// 错误描述
+	Description string `json:"error_description,omitempty"`
+}
+
+// OAuth2错误码，见RFC 6749 5.2节
+const (
+	OAuthInvalidRequest       = "invalid_request"
+	OAuthInvalidClient        = "invalid_client"
+	OAuthInvalidGrant         = "invalid_grant"
+	OAuthInvalidScope         = "invalid_scope"
+	OAuthUnauthorizedClient   = "unauthorized_client"
+	OAuthUnsupportedGrantType = "unsupported_grant_type"
+	OAuthServerError          = "server_error"
+)
+
+// NewOAuthError OAuth2错误
+func NewOAuthError(code, description string) *OAuthError {
+	return &OAuthError{
+		Error:       code,
+		Description: description,
+	}
+}
+
+// @Description OAuth2 Token内省响应
+type OAuthIntrospection struct {
+	// 是否有效
+	Active bool `json:"active"`
+	// 授权范围
+	Scope string `json:"scope,omitempty"`
+	// 客户端编号
+	ClientID string `json:"client_id,omitempty"`
+	// 用户名
+	Username string `json:"username,omitempty"`
+	// Token类型
+	TokenType string `json:"token_type,omitempty"`
+	// 过期时间(秒)
+	Exp int64 `json:"exp,omitempty"`
+}
+
+// @Description OAuth2授权确认信息
+type OAuthConsentReq struct {
+	// 客户端编号
+	ClientID string `json:"client_id"`
+	// 客户端名称
+	ClientName string `json:"client_name"`
+	// 申请的授权范围
+	Scopes []string `json:"scopes"`
+	// 用户是否已授权过相同范围
+	Consented bool `json:"consented"`
+}
+
+// @Description OAuth2授权结果
+type OAuthRedirectReq struct {
+	// 携带授权码或错误信息的回调地址
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// @Description OAuth2客户端序列化器
+type OAuthClientReq struct {
+	// 客户端编号
+	ClientID string `json:"client_id"`
+	// 名称
+	Name string `json:"name"`
+	// 回调地址
+	RedirectURIs []string `json:"redirect_uris"`
+	// 允许申请的授权范围
+	Scopes []string `json:"scopes"`
+	// 是否为机密客户端
+	Confidential bool `json:"confidential"`
+	// 创建人
+	CreatedBy string `json:"created_by"`
+	// 创建时间
+	CreatedAt int64 `json:"created_at"`
+	// 客户端密钥，仅创建时返回一次
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// BuildOAuthClient 序列化OAuth2客户端
+func BuildOAuthClient(client *model.OAuthClient) *OAuthClientReq {
+	return &OAuthClientReq{
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIList(),
+		Scopes:       client.ScopeList(),
+		Confidential: client.Confidential,
+		CreatedBy:    client.CreatedBy,
+		CreatedAt:    timeMilli(client.CreatedAt),
+	}
+}
+
+// BuildOAuthClients 序列化OAuth2客户端列表
+func BuildOAuthClients(clients []*model.OAuthClient) []*OAuthClientReq {
+	items := make([]*OAuthClientReq, 0, len(clients))
+	for _, client := range clients {
+		items = append(items, BuildOAuthClient(client))
+	}
+	return items
+}
+
+// @Description 用户已授予客户端的权限
+type OAuthGrantReq struct {
+	// 客户端编号
+	ClientID string `json:"client_id"`
+	// 已授权范围
+	Scopes []string `json:"scopes"`
+	// 授权时间
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+// BuildOAuthGrants 序列化用户的授权记录
+func BuildOAuthGrants(consents []*model.OAuthConsent) []*OAuthGrantReq {
+	items := make([]*OAuthGrantReq, 0, len(consents))
+	for _, consent := range consents {
+		items = append(items, &OAuthGrantReq{
+			ClientID:  consent.ClientID,
+			Scopes:    strings.Fields(consent.Scopes),
+			UpdatedAt: timeMilli(consent.UpdatedAt),
+		})
+	}
+	return items
+}
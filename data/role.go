@@ -0,0 +1,69 @@
+package data
+
+import "singo/model"
+
+// @Description 权限序列化器
+type PermissionReq struct {
+	// 编号
+	ID uint `json:"id"`
+	// 权限编码
+	Code string `json:"code"`
+	// 描述
+	Description string `json:"description"`
+}
+
+// @Description 角色序列化器
+type RoleReq struct {
+	// 编号
+	ID uint `json:"id"`
+	// 角色名
+	Name string `json:"name"`
+	// 描述
+	Description string `json:"description"`
+	// 权限
+	Permissions []*PermissionReq `json:"permissions"`
+	// 创建人
+	CreatedBy string `json:"created_by"`
+	// 创建时间
+	CreatedAt int64 `json:"created_at"`
+	// 修改人
+	UpdatedBy string `json:"updated_by"`
+	// 修改时间
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+// BuildPermissions 序列化权限列表
+func BuildPermissions(perms []*model.Permission) []*PermissionReq {
+	items := make([]*PermissionReq, 0, len(perms))
+	for _, perm := range perms {
+		items = append(items, &PermissionReq{
+			ID:          perm.ID,
+			Code:        perm.Code,
+			Description: perm.Description,
+		})
+	}
+	return items
+}
+
+// BuildRole 序列化角色
+func BuildRole(role *model.Role) *RoleReq {
+	return &RoleReq{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: BuildPermissions(role.Permissions),
+		CreatedBy:   role.CreatedBy,
+		CreatedAt:   timeMilli(role.CreatedAt),
+		UpdatedBy:   role.UpdatedBy,
+		UpdatedAt:   timeMilli(role.UpdatedAt),
+	}
+}
+
+// BuildRoles 序列化角色列表
+func BuildRoles(roles []*model.Role) []*RoleReq {
+	items := make([]*RoleReq, 0, len(roles))
+	for _, role := range roles {
+		items = append(items, BuildRole(role))
+	}
+	return items
+}
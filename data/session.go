@@ -0,0 +1,38 @@
+package data
+
+import "singo/cache"
+
+// @Description 登录会话序列化器
+type SessionReq struct {
+	// 会话编号
+	ID string `json:"id"`
+	// 设备名称
+	Device string `json:"device"`
+	// 客户端UA
+	UserAgent string `json:"user_agent"`
+	// 登录IP
+	IP string `json:"ip"`
+	// 登录时间
+	CreatedAt int64 `json:"created_at"`
+	// 最后活跃时间
+	LastSeen int64 `json:"last_seen"`
+	// 是否为当前会话
+	Current bool `json:"current"`
+}
+
+// BuildSessions 序列化会话列表
+func BuildSessions(sessions []*cache.Session, current string) []*SessionReq {
+	items := make([]*SessionReq, 0, len(sessions))
+	for _, session := range sessions {
+		items = append(items, &SessionReq{
+			ID:        session.ID,
+			Device:    session.Device,
+			UserAgent: session.UserAgent,
+			IP:        session.IP,
+			CreatedAt: session.CreatedAt,
+			LastSeen:  session.LastSeen,
+			Current:   session.ID == current,
+		})
+	}
+	return items
+}
@@ -8,27 +8,69 @@ type UserReq struct {
 	ID uint `json:"id"`
 	// 用户名
 	UserName string `json:"user_name"`
+	// 邮箱
+	Email string `json:"email"`
+	// 手机号
+	Phone string `json:"phone,omitempty"`
 	// 昵称
 	Nickname string `json:"nickname"`
 	// 状态
 	Status string `json:"status"`
 	// 头像
 	Avatar string `json:"avatar"`
+	// 角色
+	Roles []string `json:"roles"`
+	// 是否开启两步验证
+	MfaEnabled bool `json:"mfa_enabled"`
+	// 是否已设置密码，未设置时通过设置密码接口设置
+	HasPassword bool `json:"has_password"`
 	// 注册时间
 	CreatedAt int64 `json:"created_at"`
+	// 修改时间
+	UpdatedAt int64 `json:"updated_at"`
+	// 模拟登录的管理员，不为空表示当前为模拟登录
+	Impersonator string `json:"impersonator,omitempty"`
 	// 颁发Token
 	Token string `json:"token,omitempty"`
 	// 过期时间
 	TokenExpire int64 `json:"token_expire,omitempty"`
+	// 刷新Token
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// 刷新Token过期时间
+	RefreshExpire int64 `json:"refresh_expire,omitempty"`
 }
 
 // BuildUser 序列化用户
 func BuildUser(user *model.User) *UserReq {
 	return &UserReq{
-		ID:       user.ID,
-		UserName: user.UserName,
-		Nickname: user.Nickname,
-		Status:   user.Status,
-		Avatar:   user.Avatar,
+		ID:          user.ID,
+		UserName:    user.UserName,
+		Email:       user.Email,
+		Phone:       user.PhoneNumber(),
+		Nickname:    user.Nickname,
+		Status:      user.Status,
+		Avatar:      user.Avatar,
+		Roles:       user.RoleNames(),
+		MfaEnabled:  user.MfaEnabled,
+		HasPassword: user.PasswordDigest != "",
+		CreatedAt:   timeMilli(user.CreatedAt),
+		UpdatedAt:   timeMilli(user.UpdatedAt),
 	}
 }
+
+// BuildUsers 序列化用户列表
+func BuildUsers(users []*model.User) []*UserReq {
+	items := make([]*UserReq, 0, len(users))
+	for _, user := range users {
+		items = append(items, BuildUser(user))
+	}
+	return items
+}
+
+// @Description 头像上传结果
+type AvatarReq struct {
+	// 头像地址
+	Avatar string `json:"avatar"`
+	// 各尺寸缩略图地址，键为边长
+	Thumbnails map[int]string `json:"thumbnails"`
+}
@@ -0,0 +1,111 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"singo/conf"
+	"singo/logger"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Location IP所在地区
+type Location struct {
+	// 国家或地区代码
+	Country string
+	// 省份
+	Region string
+	// 城市
+	City string
+}
+
+// ipRange 一段连续IP对应的地区，IP统一转换为16字节便于比较
+type ipRange struct {
+	start    net.IP
+	end      net.IP
+	location Location
+}
+
+var ranges []ipRange
+var rangesOnce sync.Once
+
+// Lookup 在离线IP库中查询IP所在地区，未配置IP库或未找到时返回nil
+func Lookup(ip string) *Location {
+	rangesOnce.Do(func() {
+		file := conf.GetConfig().Login.GeoIPFile
+		if file == "" {
+			return
+		}
+		var err error
+		if ranges, err = load(file); err != nil {
+			logger.Error("加载IP库错误", err)
+		}
+	})
+
+	addr := net.ParseIP(ip).To16()
+	if addr == nil || len(ranges) == 0 {
+		return nil
+	}
+	// 找到第一个结束地址不小于addr的区间
+	i := sort.Search(len(ranges), func(i int) bool {
+		return bytes.Compare(ranges[i].end, addr) >= 0
+	})
+	if i < len(ranges) && bytes.Compare(ranges[i].start, addr) <= 0 {
+		location := ranges[i].location
+		return &location
+	}
+	return nil
+}
+
+// load 加载CSV格式的IP库，每行为 起始IP,结束IP,国家代码,省份,城市，支持IPv4及IPv6
+func load(file string) ([]ipRange, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			logger.Warn("IP库文件不存在", file)
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	var result []ipRange
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 {
+			continue
+		}
+		start := net.ParseIP(strings.TrimSpace(record[0])).To16()
+		end := net.ParseIP(strings.TrimSpace(record[1])).To16()
+		if start == nil || end == nil {
+			continue
+		}
+		r := ipRange{start: start, end: end, location: Location{Country: strings.TrimSpace(record[2])}}
+		if len(record) > 3 {
+			r.location.Region = strings.TrimSpace(record[3])
+		}
+		if len(record) > 4 {
+			r.location.City = strings.TrimSpace(record[4])
+		}
+		result = append(result, r)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].start, result[j].start) < 0
+	})
+	return result, nil
+}
@@ -0,0 +1,33 @@
+package hasher
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher bcrypt摘要算法
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+func (h *BcryptHasher) Verify(password, digest string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(digest), []byte(password)) == nil
+}
+
+func (h *BcryptHasher) Match(digest string) bool {
+	return strings.HasPrefix(digest, "$2a$") || strings.HasPrefix(digest, "$2b$") || strings.HasPrefix(digest, "$2y$")
+}
+
+func (h *BcryptHasher) Outdated(digest string) bool {
+	cost, err := bcrypt.Cost([]byte(digest))
+	return err != nil || cost < h.Cost
+}
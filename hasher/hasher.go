@@ -0,0 +1,72 @@
+package hasher
+
+import (
+	"singo/conf"
+	"sync"
+)
+
+// Hasher 密码摘要算法
+type Hasher interface {
+	// Hash 生成密码摘要
+	Hash(password string) (string, error)
+	// Verify 校验密码与摘要是否匹配
+	Verify(password, digest string) bool
+	// Match 判断摘要是否由该算法生成
+	Match(digest string) bool
+	// Outdated 判断摘要参数是否与当前配置不一致
+	Outdated(digest string) bool
+}
+
+var hasher Hasher
+var hashers []Hasher
+var hasherOnce sync.Once
+
+// GetHasher 根据配置获取当前使用的密码摘要算法
+func GetHasher() Hasher {
+	hasherOnce.Do(func() {
+		cfg := conf.GetConfig().Password
+		bcryptHasher := &BcryptHasher{Cost: cfg.BcryptCost}
+		argon2Hasher := &Argon2Hasher{
+			Memory:      cfg.Argon2.Memory,
+			Iterations:  cfg.Argon2.Iterations,
+			Parallelism: cfg.Argon2.Parallelism,
+			SaltLength:  cfg.Argon2.SaltLength,
+			KeyLength:   cfg.Argon2.KeyLength,
+		}
+		hashers = []Hasher{bcryptHasher, argon2Hasher}
+		switch cfg.Algorithm {
+		case "argon2id":
+			hasher = argon2Hasher
+		default:
+			hasher = bcryptHasher
+		}
+	})
+	return hasher
+}
+
+// SetHasher 替换当前使用的摘要算法，已有摘要仍按格式选择算法校验，测试时可使用低成本参数
+func SetHasher(h Hasher) {
+	GetHasher()
+	hasher = h
+}
+
+// Hash 使用当前算法生成密码摘要
+func Hash(password string) (string, error) {
+	return GetHasher().Hash(password)
+}
+
+// Verify 根据摘要格式选择算法校验密码，兼容历史算法生成的摘要
+func Verify(password, digest string) bool {
+	for _, h := range append([]Hasher{GetHasher()}, hashers...) {
+		if h.Match(digest) {
+			return h.Verify(password, digest)
+		}
+	}
+	return false
+}
+
+// NeedsRehash 判断摘要是否使用了旧算法或旧参数，需要在登录成功后重新生成
+func NeedsRehash(digest string) bool {
+	current := GetHasher()
+	return !current.Match(digest) || current.Outdated(digest)
+}
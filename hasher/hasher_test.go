@@ -0,0 +1,70 @@
+package hasher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		hasher Hasher
+		prefix string
+	}{
+		{"bcrypt", &BcryptHasher{Cost: 4}, "$2a$04$"},
+		{"argon2id", &Argon2Hasher{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, "$argon2id$v=19$m=1024,t=1,p=1$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			digest, err := tt.hasher.Hash("Gz8#kq2Lmv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(digest, tt.prefix) {
+				t.Errorf("Hash() = %s, want prefix %s", digest, tt.prefix)
+			}
+			if !tt.hasher.Match(digest) || tt.hasher.Outdated(digest) {
+				t.Errorf("Match/Outdated(%s) 与生成时的参数不一致", digest)
+			}
+			if !tt.hasher.Verify("Gz8#kq2Lmv", digest) {
+				t.Error("正确密码校验失败")
+			}
+			if tt.hasher.Verify("Gz8#kq2Lmw", digest) {
+				t.Error("错误密码校验通过")
+			}
+			// 同一密码每次生成的摘要都使用不同的盐
+			if again, _ := tt.hasher.Hash("Gz8#kq2Lmv"); again == digest {
+				t.Error("两次摘要相同")
+			}
+		})
+	}
+}
+
+func TestOutdated(t *testing.T) {
+	bcryptDigest, err := (&BcryptHasher{Cost: 4}).Hash("Gz8#kq2Lmv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(&BcryptHasher{Cost: 5}).Outdated(bcryptDigest) {
+		t.Error("bcrypt提高cost后摘要未过期")
+	}
+
+	argon := &Argon2Hasher{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	argonDigest, err := argon.Hash("Gz8#kq2Lmv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := *argon
+	changed.Iterations = 2
+	if !changed.Outdated(argonDigest) {
+		t.Error("argon2id修改参数后摘要未过期")
+	}
+	if argon.Match(bcryptDigest) || (&BcryptHasher{}).Match(argonDigest) {
+		t.Error("摘要格式被错误识别")
+	}
+	for _, digest := range []string{"", "$argon2id$v=19$m=1024$x$y", "$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5"} {
+		if argon.Verify("Gz8#kq2Lmv", digest) || !argon.Outdated(digest) {
+			t.Errorf("非法摘要 %q 被接受", digest)
+		}
+	}
+}
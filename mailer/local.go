@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"singo/logger"
+	"sync"
+	"time"
+)
+
+// ConsoleMailer 将邮件输出到日志，用于开发环境
+type ConsoleMailer struct{}
+
+func (m *ConsoleMailer) Send(to, subject, body string) error {
+	logger.Info("发送邮件 to:", to, " subject:", subject, "\n", body)
+	return nil
+}
+
+// FileMailer 将邮件追加写入本地文件，用于开发及测试环境
+type FileMailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileMailer(path string) *FileMailer {
+	if path == "" {
+		path = "./mail.log"
+	}
+	return &FileMailer{path: path}
+}
+
+func (m *FileMailer) Send(to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), to, subject, body)
+	return err
+}
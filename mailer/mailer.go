@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"singo/conf"
+	"sync"
+)
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	// Send 发送纯文本邮件
+	Send(to, subject, body string) error
+}
+
+var mailer Mailer
+var mailerOnce sync.Once
+
+// GetMailer 根据配置获取邮件发送器，开发环境可使用console或file
+func GetMailer() Mailer {
+	mailerOnce.Do(func() {
+		cfg := conf.GetConfig().Mail
+		switch cfg.Driver {
+		case "smtp":
+			mailer = NewSMTPMailer(cfg)
+		case "file":
+			mailer = NewFileMailer(cfg.File)
+		default:
+			mailer = &ConsoleMailer{}
+		}
+	})
+	return mailer
+}
@@ -8,12 +8,15 @@ import (
 	"singo/logger"
 	"singo/model"
 	"singo/server"
+	"singo/service"
 )
 
 func main() {
 
 	cache.InitRedis()
 	model.InitMysql()
+	// 定时清除注销账号的个人信息
+	service.StartPurgeJob()
 	// 装载路由
 	r := server.NewRouter()
 	if err := r.Run(fmt.Sprintf(":%d", conf.GetConfig().Server.Port)); err != nil {
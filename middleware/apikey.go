@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"singo/cache"
+	"singo/logger"
+	"singo/model"
+	"singo/util"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ApiKeyHeader 携带API Key的请求头
+const ApiKeyHeader = "X-Api-Key"
+
+// ApiKeyPrefix API Key固定前缀，格式为 gk_<前缀>_<密钥>
+const ApiKeyPrefix = "gk"
+
+// AnyAuthMiddleware 同时支持API Key及登录Token认证
+func AnyAuthMiddleware() gin.HandlerFunc {
+	auth := AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader(ApiKeyHeader) == "" {
+			auth(c)
+			return
+		}
+		apiKeyAuth(c)
+	}
+}
+
+// apiKeyAuth 校验API Key，并将用户名、角色及授权范围写入Context
+func apiKeyAuth(c *gin.Context) {
+	unauthorized := func() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Abort()
+	}
+
+	parts := strings.SplitN(c.GetHeader(ApiKeyHeader), "_", 3)
+	if len(parts) != 3 || parts[0] != ApiKeyPrefix {
+		unauthorized()
+		return
+	}
+
+	rep := model.GetDbClient()
+	key, err := rep.GetApiKeyByPrefix(parts[1])
+	if err != nil || key.Expired() {
+		unauthorized()
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(util.HashToken(c.GetHeader(ApiKeyHeader)))) != 1 {
+		unauthorized()
+		return
+	}
+
+	var user model.User
+	if err = rep.Preload("Roles").First(&user, key.UserID).Error; err != nil || user.Status != model.Active {
+		unauthorized()
+		return
+	}
+	if suspended, err := cache.GetRedisClient().IsSuspended(user.UserName); err != nil || suspended {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+		c.Abort()
+		return
+	}
+
+	if err = rep.TouchApiKey(key.ID); err != nil {
+		logger.Error("更新API Key使用时间错误", err)
+	}
+
+	c.Set("username", user.UserName)
+	c.Set("roles", user.RoleNames())
+	c.Set("scopes", key.ScopeList())
+	c.Set("api_key_id", key.ID)
+	c.Request = c.Request.WithContext(model.WithOperator(c.Request.Context(), user.UserName))
+
+	c.Next()
+}
+
+// RequireSession 要求通过登录Token访问，API Key不可调用账号安全相关接口
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("session_id") == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Login session required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
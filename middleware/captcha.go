@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"singo/cache"
+	"singo/captcha"
+	"singo/conf"
+	"singo/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 验证码请求头
+const (
+	CaptchaIDHeader     = "X-Captcha-Id"
+	CaptchaAnswerHeader = "X-Captcha-Answer"
+)
+
+// Captcha 人机验证，scene需在captcha.routes中配置才会生效
+// 配置了fail_threshold时，login场景在同一IP登录失败达到次数后才要求验证码，其他场景始终要求
+func Captcha(scene string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := conf.GetConfig().Captcha
+		if !captchaRequired(cfg, scene, c.ClientIP()) {
+			c.Next()
+			return
+		}
+
+		id := c.GetHeader(CaptchaIDHeader)
+		answer := c.GetHeader(CaptchaAnswerHeader)
+		if id == "" || answer == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Captcha required"})
+			c.Abort()
+			return
+		}
+
+		stored, err := cache.GetRedisClient().TakeCaptcha(id)
+		if err != nil && err != cache.Nil {
+			logger.Error("查询验证码错误", err)
+		}
+		if err != nil || !captcha.Verify(stored, answer, cfg.Tolerance) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid captcha"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func captchaRequired(cfg conf.CaptchaConfig, scene, ip string) bool {
+	enabled := false
+	for _, route := range cfg.Routes {
+		if route == scene {
+			enabled = true
+			break
+		}
+	}
+	if !enabled {
+		return false
+	}
+	// 失败计数只统计登录失败，仅对login场景生效
+	if scene != "login" || cfg.FailThreshold <= 0 {
+		return true
+	}
+	count, err := cache.GetRedisClient().GetLoginFail(cache.LoginIPSubject(ip))
+	if err != nil {
+		logger.Error("查询登录失败次数错误", err)
+		return true
+	}
+	return count >= cfg.FailThreshold
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"regexp"
+	"singo/sign"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -11,7 +12,11 @@ import (
 func Cors() gin.HandlerFunc {
 	config := cors.DefaultConfig()
 	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Cookie"}
+	config.AllowHeaders = []string{
+		"Origin", "Content-Length", "Content-Type", "Cookie", "Authorization",
+		CaptchaIDHeader, CaptchaAnswerHeader, ApiKeyHeader,
+		sign.HeaderAppKey, sign.HeaderTimestamp, sign.HeaderNonce, sign.HeaderSignature,
+	}
 	if gin.Mode() == gin.ReleaseMode {
 		// 生产环境需要配置跨域域名，否则403
 		config.AllowOrigins = []string{"http://www.example.com"}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"singo/cache"
+	"singo/logger"
+	"singo/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkImpersonation 校验模拟登录Token是否仍有效，管理员结束模拟或到期后立即失效
+func checkImpersonation(claims *Claims, tokenString string) bool {
+	imp, err := cache.GetRedisClient().GetImpersonation(claims.SessionID)
+	if err != nil {
+		return false
+	}
+	return imp.UserName == claims.Username && imp.Actor == claims.Actor && imp.Token == tokenString
+}
+
+// auditImpersonation 模拟登录期间的写操作全部记录审计日志
+func auditImpersonation(c *gin.Context, claims *Claims) {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+		return
+	}
+	err := model.GetDbClient().AddAuditLog(&model.AuditLog{
+		Actor:  claims.Actor,
+		Action: model.AuditImpersonateRequest,
+		Target: claims.Username,
+		Detail: fmt.Sprintf("%s %s", c.Request.Method, c.Request.URL.Path),
+		IP:     c.ClientIP(),
+	})
+	if err != nil {
+		logger.Error("写入审计日志错误", err)
+	}
+}
+
+// DenyImpersonation 禁止模拟登录访问，用于修改密码、两步验证等敏感操作
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("actor") != "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed while impersonating"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
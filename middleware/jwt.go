@@ -4,11 +4,18 @@ import (
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"net/http"
-	"singo/conf"
+	"singo/cache"
+	"singo/model"
 )
 
 type Claims struct {
 	Username string `json:"username"`
+	// 会话编号
+	SessionID string `json:"sid"`
+	// 角色
+	Roles []string `json:"roles,omitempty"`
+	// 模拟登录时实际操作的管理员
+	Actor string `json:"act,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -21,9 +28,7 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(conf.GetConfig().Server.Secret), nil
-		})
+		token, err := ParseToken(tokenString, &Claims{})
 
 		if err != nil || !token.Valid {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
@@ -38,9 +43,43 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// 校验Token是否仍为服务端记录的有效会话，注销或被强制下线后立即失效
+		if claims.Actor != "" {
+			if !checkImpersonation(claims, tokenString) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token revoked"})
+				c.Abort()
+				return
+			}
+		} else {
+			session, err := cache.GetRedisClient().GetSession(claims.SessionID)
+			if err != nil || session.UserName != claims.Username || session.Token != tokenString {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Token revoked"})
+				c.Abort()
+				return
+			}
+			_ = cache.GetRedisClient().TouchSession(claims.SessionID)
+		}
+
+		// 被封禁的用户即使持有未过期的Token也立即拒绝
+		if suspended, err := cache.GetRedisClient().IsSuspended(claims.Username); err != nil || suspended {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended"})
+			c.Abort()
+			return
+		}
+
 		username := claims.Username // 这里获取了用户名信息
 		// 可以将用户名信息存储在Context中，以便后续处理使用
 		c.Set("username", username)
+		c.Set("session_id", claims.SessionID)
+		c.Set("roles", claims.Roles)
+		// 写操作的操作人通过请求上下文传给服务，模拟登录时记录实际操作的管理员
+		operator := username
+		if claims.Actor != "" {
+			operator = claims.Actor
+			c.Set("actor", claims.Actor)
+			auditImpersonation(c, claims)
+		}
+		c.Request = c.Request.WithContext(model.WithOperator(c.Request.Context(), operator))
 
 		c.Next()
 	}
@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"singo/conf"
+	"singo/logger"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// verifyKey 用于验证签名的公钥
+type verifyKey struct {
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+// keySet 签名密钥集合
+type keySet struct {
+	// 当前签名密钥编号，为空表示使用HS256
+	activeKid string
+	signKey   crypto.PrivateKey
+	method    jwt.SigningMethod
+	// 全部可用于验证的密钥，按kid索引
+	verifyKeys map[string]*verifyKey
+}
+
+var keys *keySet
+var keysOnce sync.Once
+
+func getKeys() *keySet {
+	keysOnce.Do(func() {
+		keys = loadKeys(conf.GetConfig().Jwt)
+	})
+	return keys
+}
+
+// loadKeys 从PEM文件加载密钥
+func loadKeys(cfg conf.JwtConfig) *keySet {
+	set := &keySet{
+		activeKid:  cfg.ActiveKid,
+		verifyKeys: map[string]*verifyKey{},
+	}
+	for _, item := range cfg.Keys {
+		var publicKey crypto.PublicKey
+		if item.Private != "" {
+			privateKey, err := readPrivateKey(item.Private)
+			if err != nil {
+				logger.Panic("读取JWT私钥出错", item.Kid, err)
+			}
+			publicKey = privateKey.(interface{ Public() crypto.PublicKey }).Public()
+			if item.Kid == cfg.ActiveKid {
+				set.signKey = privateKey
+			}
+		} else {
+			key, err := readPublicKey(item.Public)
+			if err != nil {
+				logger.Panic("读取JWT公钥出错", item.Kid, err)
+			}
+			publicKey = key
+		}
+
+		method, err := methodOf(publicKey)
+		if err != nil {
+			logger.Panic("JWT密钥类型错误", item.Kid, err)
+		}
+		set.verifyKeys[item.Kid] = &verifyKey{method: method, key: publicKey}
+		if item.Kid == cfg.ActiveKid {
+			set.method = method
+		}
+	}
+	if set.activeKid != "" && set.signKey == nil {
+		logger.Panic("未找到JWT签名私钥", set.activeKid)
+	}
+	return set
+}
+
+func readPEM(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s 不是有效的PEM文件", path)
+	}
+	return block.Bytes, nil
+}
+
+func readPrivateKey(path string) (crypto.PrivateKey, error) {
+	der, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParsePKCS8PrivateKey(der)
+}
+
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	der, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParsePKIXPublicKey(der)
+}
+
+// methodOf 根据密钥类型确定签名算法
+func methodOf(key crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return SigningMethodEd25519, nil
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型 %T", key)
+	}
+}
+
+// SignToken 使用当前签名密钥签发Token
+func SignToken(claims jwt.Claims) (string, error) {
+	set := getKeys()
+	if set.activeKid == "" {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(conf.GetConfig().Server.Secret))
+	}
+
+	token := jwt.NewWithClaims(set.method, claims)
+	token.Header["kid"] = set.activeKid
+	return token.SignedString(set.signKey)
+}
+
+// ParseToken 校验并解析Token，根据kid选择验证密钥
+func ParseToken(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	set := getKeys()
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || set.activeKid != "" {
+				return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+			}
+			return []byte(conf.GetConfig().Server.Secret), nil
+		}
+
+		key, ok := set.verifyKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown kid %s", kid)
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return key.key, nil
+	})
+}
+
+// JWKS 返回全部验证公钥的JWK集合
+func JWKS() map[string]interface{} {
+	set := getKeys()
+	items := make([]map[string]string, 0, len(set.verifyKeys))
+	for kid, key := range set.verifyKeys {
+		jwk := map[string]string{
+			"kid": kid,
+			"use": "sig",
+			"alg": key.method.Alg(),
+		}
+		switch publicKey := key.key.(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+		items = append(items, jwk)
+	}
+	return map[string]interface{}{"keys": items}
+}
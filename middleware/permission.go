@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"singo/logger"
+	"singo/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission 权限校验，需在AuthMiddleware之后使用，要求拥有全部指定权限
+// 使用API Key访问时，权限还需在Key的授权范围内
+func RequirePermission(codes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roles := c.GetStringSlice("roles")
+		scopes := c.GetStringSlice("scopes")
+		for _, code := range codes {
+			ok, err := model.GetDbClient().HasPermission(roles, code)
+			if err != nil {
+				logger.Error("查询权限错误", err)
+			}
+			if ok && len(scopes) > 0 {
+				ok = inScopes(scopes, code)
+			}
+			if !ok {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+func inScopes(scopes []string, code string) bool {
+	for _, scope := range scopes {
+		if scope == code {
+			return true
+		}
+	}
+	return false
+}
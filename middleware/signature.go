@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"singo/cache"
+	"singo/conf"
+	"singo/logger"
+	"singo/model"
+	"singo/sign"
+	"singo/util"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSignedBody 签名请求体的最大长度
+const maxSignedBody = 10 << 20
+
+// SignatureMiddleware 开放接口签名校验，拒绝过期的时间戳及重复的随机数
+func SignatureMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reject := func(msg string) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+			c.Abort()
+		}
+
+		appKey := c.GetHeader(sign.HeaderAppKey)
+		timestamp := c.GetHeader(sign.HeaderTimestamp)
+		nonce := c.GetHeader(sign.HeaderNonce)
+		signature := c.GetHeader(sign.HeaderSignature)
+		if appKey == "" || timestamp == "" || signature == "" || len(nonce) < 8 || len(nonce) > 64 {
+			reject("Missing signature headers")
+			return
+		}
+
+		window := conf.GetConfig().Open.TimestampWindow
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			reject("Invalid timestamp")
+			return
+		}
+		if diff := time.Since(time.Unix(ts, 0)); diff > window || diff < -window {
+			reject("Timestamp expired")
+			return
+		}
+
+		app, err := model.GetDbClient().GetAppClient(appKey)
+		if err != nil || app.Status != model.AppActive {
+			reject("Invalid app key")
+			return
+		}
+		secret, err := util.Decrypt(app.AppSecret, conf.GetConfig().Server.EncryptKey)
+		if err != nil {
+			logger.Error("解密应用密钥错误", err)
+			reject("Invalid app key")
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSignedBody))
+		if err != nil {
+			reject("Invalid body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		toSign := sign.StringToSign(c.Request.Method, c.Request.URL.Path, c.Request.URL.Query(), timestamp, nonce, body)
+		if !sign.Verify(secret, toSign, signature) {
+			reject("Invalid signature")
+			return
+		}
+
+		// 签名通过后再记录随机数，时间窗口内同一随机数只能使用一次
+		ok, err := cache.GetRedisClient().UseNonce(appKey, nonce, 2*window)
+		if err != nil || !ok {
+			reject("Replayed request")
+			return
+		}
+
+		c.Set("app_key", appKey)
+		c.Next()
+	}
+}
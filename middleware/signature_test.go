@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"singo/conf"
+	"singo/model"
+	"singo/sign"
+	"singo/testutil"
+	"singo/util"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testAppKey    = "app_test"
+	testAppSecret = "secret_test"
+)
+
+// setupSignature 创建测试应用并返回挂载签名校验的路由
+func setupSignature(t *testing.T) *gin.Engine {
+	t.Helper()
+	testutil.Setup(t)
+	secret, err := util.Encrypt(testAppSecret, conf.GetConfig().Server.EncryptKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &model.AppClient{AppKey: testAppKey, AppSecret: secret, Name: "test", Status: model.AppActive}
+	if err = model.GetDbClient().Create(app).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/open/ping", SignatureMiddleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("app_key"))
+	})
+	return r
+}
+
+// signedRequest 使用指定的时间戳及随机数构造签名请求
+func signedRequest(secret string, ts time.Time, nonce, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/open/ping?b=2&a=1", strings.NewReader(body))
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	toSign := sign.StringToSign(req.Method, req.URL.Path, req.URL.Query(), timestamp, nonce, []byte(body))
+	req.Header.Set(sign.HeaderAppKey, testAppKey)
+	req.Header.Set(sign.HeaderTimestamp, timestamp)
+	req.Header.Set(sign.HeaderNonce, nonce)
+	req.Header.Set(sign.HeaderSignature, sign.Signature(secret, toSign))
+	return req
+}
+
+func serve(r *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestSignatureAccepted(t *testing.T) {
+	r := setupSignature(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/open/ping?b=2&a=1", strings.NewReader(`{"k":"v"}`))
+	if err := sign.SignRequest(req, testAppKey, testAppSecret); err != nil {
+		t.Fatal(err)
+	}
+	if w := serve(r, req); w.Code != http.StatusOK || w.Body.String() != testAppKey {
+		t.Errorf("签名请求 = %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSignatureRejected(t *testing.T) {
+	r := setupSignature(t)
+	window := conf.GetConfig().Open.TimestampWindow
+
+	tampered := signedRequest(testAppSecret, time.Now(), "nonce0001", `{"k":"v"}`)
+	tampered.Body = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"k":"x"}`)).Body
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want string
+	}{
+		{"错误密钥", signedRequest("wrong", time.Now(), "nonce0002", ""), "Invalid signature"},
+		{"篡改请求体", tampered, "Invalid signature"},
+		{"过期时间戳", signedRequest(testAppSecret, time.Now().Add(-window-time.Minute), "nonce0003", ""), "Timestamp expired"},
+		{"未来时间戳", signedRequest(testAppSecret, time.Now().Add(window+time.Minute), "nonce0004", ""), "Timestamp expired"},
+		{"随机数过短", signedRequest(testAppSecret, time.Now(), "short", ""), "Missing signature headers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(r, tt.req); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("响应 = %d %s, want %s", w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSignatureReplay(t *testing.T) {
+	r := setupSignature(t)
+
+	ts := time.Now()
+	if w := serve(r, signedRequest(testAppSecret, ts, "nonce0001", "")); w.Code != http.StatusOK {
+		t.Fatalf("首次请求 = %d %s", w.Code, w.Body.String())
+	}
+	// 重放相同随机数的请求被拒绝
+	if w := serve(r, signedRequest(testAppSecret, ts, "nonce0001", "")); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "Replayed request") {
+		t.Errorf("重放请求 = %d %s", w.Code, w.Body.String())
+	}
+	// 签名错误的请求不占用随机数
+	if w := serve(r, signedRequest("wrong", ts, "nonce0002", "")); w.Code != http.StatusUnauthorized {
+		t.Fatalf("错误签名 = %d", w.Code)
+	}
+	if w := serve(r, signedRequest(testAppSecret, ts, "nonce0002", "")); w.Code != http.StatusOK {
+		t.Errorf("随机数被错误签名占用 = %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// @Description 个人API Key模型
+type ApiKey struct {
+	// 编号
+	ID uint `gorm:"primarykey"`
+	// 用户编号
+	UserID uint `gorm:"index"`
+	// 名称
+	Name string `gorm:"size:50"`
+	// 前缀，用于识别Key，明文展示
+	Prefix string `gorm:"size:16;uniqueIndex"`
+	// Key摘要
+	KeyHash string `gorm:"size:64"`
+	// 授权范围，逗号分隔的权限编码，为空表示继承用户全部权限
+	Scopes string
+	// 过期时间，为空表示永不过期
+	ExpiresAt *time.Time
+	// 最后使用时间
+	LastUsedAt *time.Time
+	Audit
+}
+
+// ScopeList 授权范围列表
+func (key *ApiKey) ScopeList() []string {
+	if key.Scopes == "" {
+		return nil
+	}
+	return strings.Split(key.Scopes, ",")
+}
+
+// Expired 判断Key是否已过期
+func (key *ApiKey) Expired() bool {
+	return key.ExpiresAt != nil && key.ExpiresAt.Before(time.Now())
+}
+
+// GetApiKeys 获取用户全部API Key
+func (rep *MyDb) GetApiKeys(userID uint) (array []*ApiKey, err error) {
+	err = rep.Where("user_id = ?", userID).Order("id desc").Find(&array).Error
+	return
+}
+
+// GetApiKeyByPrefix 用前缀获取API Key
+func (rep *MyDb) GetApiKeyByPrefix(prefix string) (key *ApiKey, err error) {
+	err = rep.Where("prefix = ?", prefix).First(&key).Error
+	return
+}
+
+// TouchApiKey 更新API Key最后使用时间
+func (rep *MyDb) TouchApiKey(id uint) error {
+	return rep.Model(&ApiKey{}).Where("id = ?", id).UpdateColumn("last_used_at", time.Now()).Error
+}
@@ -0,0 +1,35 @@
+package model
+
+// @Description 开放平台应用模型
+type AppClient struct {
+	// 编号
+	ID uint `gorm:"primarykey"`
+	// 应用Key
+	AppKey string `gorm:"size:32;uniqueIndex"`
+	// 加密后的应用密钥
+	AppSecret string `gorm:"size:255" json:"-"`
+	// 名称
+	Name string `gorm:"size:100"`
+	// 状态
+	Status string `gorm:"size:20"`
+	Audit
+}
+
+const (
+	// AppActive 应用可用
+	AppActive string = "active"
+	// AppDisabled 应用已停用
+	AppDisabled string = "disabled"
+)
+
+// GetAppClient 用应用Key获取应用
+func (rep *MyDb) GetAppClient(appKey string) (app *AppClient, err error) {
+	err = rep.Where("app_key = ?", appKey).First(&app).Error
+	return
+}
+
+// GetAppClients 获取全部应用
+func (rep *MyDb) GetAppClients() (array []*AppClient, err error) {
+	err = rep.Order("id desc").Find(&array).Error
+	return
+}
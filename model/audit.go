@@ -0,0 +1,41 @@
+package model
+
+import (
+	"singo/req"
+)
+
+// 审计操作类型
+const (
+	// AuditImpersonate 管理员开始模拟登录
+	AuditImpersonate = "impersonate"
+	// AuditImpersonateRequest 模拟登录期间的写操作
+	AuditImpersonateRequest = "impersonate.request"
+)
+
+// @Description 审计日志
+type AuditLog struct {
+	// 编号
+	ID uint `gorm:"primarykey"`
+	// 操作人
+	Actor string `gorm:"size:30;index"`
+	// 操作类型
+	Action string `gorm:"size:50;index"`
+	// 操作对象
+	Target string `gorm:"size:100;index"`
+	// 详情
+	Detail string `gorm:"size:1000"`
+	// 操作IP
+	IP string `gorm:"size:50"`
+	Audit
+}
+
+// AddAuditLog 写入审计日志
+func (rep *MyDb) AddAuditLog(log *AuditLog) error {
+	return rep.Create(log).Error
+}
+
+// GetAuditLogs 分页获取审计日志，按时间倒序
+func (rep *MyDb) GetAuditLogs(param *req.PageReq) (total int64, next string, array []*AuditLog, err error) {
+	total, next, err = NewQuery().Sort("-id", idSortable).Find(rep.DB, param, &array)
+	return
+}
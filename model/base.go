@@ -0,0 +1,118 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Audit 审计字段，所有模型都需嵌入
+// CreatedAt、UpdatedAt由GORM自动维护，CreatedBy、UpdatedBy由回调从上下文中的操作人填充
+// 登录后的请求由认证中间件将操作人写入请求上下文，服务通过WithCtx传入；未登录的流程使用As指定
+type Audit struct {
+	// 创建时间
+	CreatedAt time.Time
+	// 修改时间
+	UpdatedAt time.Time
+	// 创建人
+	CreatedBy string `gorm:"size:30"`
+	// 修改人
+	UpdatedBy string `gorm:"size:30"`
+}
+
+type operatorKey struct{}
+
+// WithOperator 在上下文中记录操作人
+func WithOperator(ctx context.Context, operator string) context.Context {
+	return context.WithValue(ctx, operatorKey{}, operator)
+}
+
+// OperatorFrom 获取上下文中的操作人
+func OperatorFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	operator, _ := ctx.Value(operatorKey{}).(string)
+	return operator
+}
+
+// WithCtx 使用请求上下文，之后的写操作记录上下文中的操作人
+func (rep *MyDb) WithCtx(ctx context.Context) *MyDb {
+	return &MyDb{rep.WithContext(ctx)}
+}
+
+// As 指定操作人，之后的写操作会记录到CreatedBy、UpdatedBy
+func (rep *MyDb) As(operator string) *MyDb {
+	ctx := rep.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return rep.WithCtx(WithOperator(ctx, operator))
+}
+
+// registerAuditCallbacks 注册填充操作人的回调
+func registerAuditCallbacks(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("audit:create", setCreatedBy); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("audit:update", setUpdatedBy); err != nil {
+		return err
+	}
+	return db.Callback().Delete().Before("gorm:delete").Register("audit:delete", setDeletedBy)
+}
+
+func setCreatedBy(db *gorm.DB) {
+	operator := OperatorFrom(db.Statement.Context)
+	if operator == "" || db.Statement.Schema == nil {
+		return
+	}
+	if db.Statement.Schema.LookUpField("CreatedBy") != nil {
+		db.Statement.SetColumn("CreatedBy", operator, true)
+	}
+	if db.Statement.Schema.LookUpField("UpdatedBy") != nil {
+		db.Statement.SetColumn("UpdatedBy", operator, true)
+	}
+}
+
+func setUpdatedBy(db *gorm.DB) {
+	operator := OperatorFrom(db.Statement.Context)
+	if operator == "" || db.Statement.Schema == nil {
+		return
+	}
+	if db.Statement.Schema.LookUpField("UpdatedBy") != nil {
+		db.Statement.SetColumn("UpdatedBy", operator, true)
+	}
+}
+
+// setDeletedBy 软删除时记录删除的操作人
+// 软删除生成的UPDATE语句只包含deleted_at，无法追加字段，这里在删除前单独更新UpdatedBy
+func setDeletedBy(db *gorm.DB) {
+	stmt := db.Statement
+	operator := OperatorFrom(stmt.Context)
+	if db.Error != nil || operator == "" || stmt.Schema == nil || stmt.Unscoped || !softDeleted(stmt.Schema) {
+		return
+	}
+	field := stmt.Schema.LookUpField("UpdatedBy")
+	if field == nil {
+		return
+	}
+	tx := db.Session(&gorm.Session{NewDB: true}).Model(stmt.Model)
+	if where, ok := stmt.Clauses["WHERE"]; ok {
+		tx = tx.Clauses(where.Expression)
+	}
+	if err := tx.UpdateColumn(field.DBName, operator).Error; err != nil {
+		_ = db.AddError(err)
+	}
+}
+
+// softDeleted 模型是否使用软删除
+func softDeleted(s *schema.Schema) bool {
+	for _, c := range s.DeleteClauses {
+		if _, ok := c.(gorm.SoftDeleteDeleteClause); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package model
+
+// @Description 外部身份绑定模型
+type Identity struct {
+	// 编号
+	ID uint `gorm:"primarykey"`
+	// 用户编号
+	UserID uint `gorm:"index"`
+	// 身份提供方名称
+	Provider string `gorm:"size:50;uniqueIndex:idx_identity"`
+	// 身份提供方内的用户唯一标识
+	Subject string `gorm:"size:255;uniqueIndex:idx_identity"`
+	// 身份提供方返回的邮箱
+	Email string `gorm:"size:100"`
+	Audit
+}
+
+// GetIdentity 用身份提供方及唯一标识获取绑定
+func (rep *MyDb) GetIdentity(provider, subject string) (identity *Identity, err error) {
+	err = rep.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	return
+}
+
+// GetIdentities 获取用户全部外部身份
+func (rep *MyDb) GetIdentities(userID uint) (array []*Identity, err error) {
+	err = rep.Where("user_id = ?", userID).Order("id").Find(&array).Error
+	return
+}
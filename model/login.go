@@ -0,0 +1,76 @@
+package model
+
+import (
+	"singo/req"
+	"time"
+)
+
+// 登录失败原因
+const (
+	// LoginUserNotFound 用户不存在
+	LoginUserNotFound = "user_not_found"
+	// LoginBadPassword 密码错误
+	LoginBadPassword = "bad_password"
+	// LoginInactive 账号未激活
+	LoginInactive = "inactive"
+	// LoginSuspended 账号被封禁
+	LoginSuspended = "suspended"
+	// LoginMfaFailed 两步验证失败
+	LoginMfaFailed = "mfa_failed"
+	// LoginPendingDeletion 账号注销中
+	LoginPendingDeletion = "pending_deletion"
+	// LoginThrottled 登录失败次数过多被限制
+	LoginThrottled = "throttled"
+)
+
+// @Description 登录历史
+type LoginHistory struct {
+	// 编号
+	ID uint `gorm:"primarykey"`
+	// 用户编号，用户不存在时为0
+	UserID uint `gorm:"index"`
+	// 登录时填写的用户名
+	UserName string `gorm:"size:30;index"`
+	// 是否成功
+	Success bool
+	// 失败原因
+	Reason string `gorm:"size:30"`
+	// 登录IP
+	IP string `gorm:"size:50"`
+	// 客户端UA
+	UserAgent string `gorm:"size:500"`
+	// 设备名称
+	Device string `gorm:"size:50"`
+	// 浏览器
+	Browser string `gorm:"size:30"`
+	// 操作系统
+	OS string `gorm:"size:30"`
+	// 设备类型
+	DeviceType string `gorm:"size:20"`
+	// 国家或地区代码
+	Country string `gorm:"size:10"`
+	// 省份
+	Region string `gorm:"size:50"`
+	// 城市
+	City string `gorm:"size:50"`
+	// 登录时间，覆盖Audit中的同名字段以保留按时间查询的索引
+	CreatedAt time.Time `gorm:"index"`
+	Audit
+}
+
+// AddLoginHistory 写入登录历史
+func (rep *MyDb) AddLoginHistory(history *LoginHistory) error {
+	return rep.Create(history).Error
+}
+
+// GetLoginHistory 分页获取用户的登录历史，按时间倒序
+func (rep *MyDb) GetLoginHistory(userID uint, param *req.PageReq) (total int64, next string, array []*LoginHistory, err error) {
+	total, next, err = NewQuery().Equal("user_id", userID).Sort("-id", idSortable).Find(rep.DB, param, &array)
+	return
+}
+
+// CountLoginSuccess 统计用户满足条件的成功登录次数
+func (rep *MyDb) CountLoginSuccess(userID uint, conds map[string]interface{}) (count int64, err error) {
+	err = rep.Model(&LoginHistory{}).Where("user_id = ? AND success = ?", userID, true).Where(conds).Count(&count).Error
+	return
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// @Description 两步验证恢复码
+type RecoveryCode struct {
+	// 编号
+	ID uint `gorm:"primarykey"`
+	// 用户编号
+	UserID uint `gorm:"index"`
+	// 恢复码摘要
+	CodeHash string `gorm:"size:64"`
+	// 使用时间
+	UsedAt *time.Time
+	Audit
+}
+
+// ReplaceRecoveryCodes 重新生成用户的恢复码，旧恢复码全部作废
+func (rep *MyDb) ReplaceRecoveryCodes(userID uint, hashes []string) error {
+	return rep.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]*RecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, &RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// GetRecoveryCodes 获取用户的全部恢复码
+func (rep *MyDb) GetRecoveryCodes(userID uint) (array []*RecoveryCode, err error) {
+	err = rep.Where("user_id = ?", userID).Order("id").Find(&array).Error
+	return
+}
+
+// UseRecoveryCode 使用恢复码，每个恢复码只能使用一次
+func (rep *MyDb) UseRecoveryCode(userID uint, hash string) (bool, error) {
+	res := rep.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+// DeleteRecoveryCodes 删除用户全部恢复码
+func (rep *MyDb) DeleteRecoveryCodes(userID uint) error {
+	return rep.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error
+}
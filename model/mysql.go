@@ -31,8 +31,8 @@ func GetDbClient() *MyDb {
 // Database 在中间件中初始化mysql链接
 func InitMysql() {
 
-	// 构建 MySQL DSN
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s",
+	// 构建 MySQL DSN，parseTime将DATETIME解析为time.Time
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		config.Database.User,
 		config.Database.Password,
 		config.Database.Host,
@@ -69,14 +69,50 @@ func InitMysql() {
 	sqlDB.SetMaxIdleConns(10)
 	// 打开
 	sqlDB.SetMaxOpenConns(20)
+	UseDb(db)
+}
+
+// UseDb 使用指定的数据库连接，注册回调并更新数据结构，测试时可传入其他数据库
+func UseDb(db *gorm.DB) {
+	if err := registerAuditCallbacks(db); err != nil {
+		panic(err)
+	}
 	DbClient = db
 	// 更新数据结构
 	migration()
 }
 
 func migration() {
-	// 自动迁移模式
-	_ = DbClient.AutoMigrate(
+	models := []interface{}{
 		&User{},
-	)
+		&Role{},
+		&Permission{},
+		&RecoveryCode{},
+		&ApiKey{},
+		&OAuthClient{},
+		&OAuthConsent{},
+		&Identity{},
+		&AppClient{},
+		&PasswordHistory{},
+		&AuditLog{},
+		&LoginHistory{},
+	}
+	// 自动迁移模式
+	_ = DbClient.AutoMigrate(models...)
+	backfillAudit(models)
+	seedRoles()
+}
+
+// backfillAudit 为新增审计字段前已存在的记录补齐创建及修改时间，避免序列化出零值时间
+func backfillAudit(models []interface{}) {
+	now := time.Now()
+	db := DbClient.Unscoped().Session(&gorm.Session{SkipHooks: true})
+	for _, m := range models {
+		if err := db.Model(m).Where("created_at IS NULL").UpdateColumn("created_at", now).Error; err != nil {
+			logger.Error("补齐创建时间错误", err)
+		}
+		if err := db.Model(m).Where("updated_at IS NULL").UpdateColumn("updated_at", gorm.Expr("created_at")).Error; err != nil {
+			logger.Error("补齐修改时间错误", err)
+		}
+	}
 }
@@ -0,0 +1,55 @@
+package model_test
+
+import (
+	"path/filepath"
+	"singo/data"
+	"singo/model"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMigrationBackfillAudit(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 新增审计字段前的表结构及数据
+	type legacyRole struct {
+		ID          uint   `gorm:"primarykey"`
+		Name        string `gorm:"uniqueIndex;size:50"`
+		Description string
+	}
+	legacy := db.Table("roles")
+	if err = legacy.AutoMigrate(&legacyRole{}); err == nil {
+		err = legacy.Create(&legacyRole{Name: "legacy"}).Error
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model.UseDb(db)
+	var role model.Role
+	if err = db.Where("name = ?", "legacy").First(&role).Error; err != nil {
+		t.Fatal(err)
+	}
+	if role.CreatedAt.IsZero() || role.UpdatedAt.IsZero() {
+		t.Errorf("迁移后审计时间未补齐 %+v", role)
+	}
+	if item := data.BuildRole(&role); item.CreatedAt <= 0 {
+		t.Errorf("序列化的创建时间 = %d", item.CreatedAt)
+	}
+
+	// 零值时间序列化为0
+	if item := data.BuildRole(&model.Role{}); item.CreatedAt != 0 || item.UpdatedAt != 0 {
+		t.Errorf("零值时间序列化 = %+v", item)
+	}
+
+	if !db.Migrator().HasIndex(&model.LoginHistory{}, "CreatedAt") {
+		t.Error("登录历史缺少创建时间索引")
+	}
+}
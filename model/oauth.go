@@ -0,0 +1,97 @@
+package model
+
+import (
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// @Description OAuth2客户端模型
+type OAuthClient struct {
+	// 编号
+	ID uint `gorm:"primarykey"`
+	// 客户端编号
+	ClientID string `gorm:"size:32;uniqueIndex"`
+	// 客户端密钥摘要，公开客户端为空
+	SecretHash string `gorm:"size:64" json:"-"`
+	// 名称
+	Name string `gorm:"size:100"`
+	// 回调地址，空格分隔
+	RedirectURIs string `gorm:"size:2000"`
+	// 允许申请的授权范围，空格分隔
+	Scopes string `gorm:"size:1000"`
+	// 是否为机密客户端(可保存密钥的服务端应用)
+	Confidential bool
+	Audit
+}
+
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+// @Description OAuth2用户授权记录
+type OAuthConsent struct {
+	// 编号
+	ID uint `gorm:"primarykey"`
+	// 用户编号
+	UserID uint `gorm:"uniqueIndex:idx_oauth_consent"`
+	// 客户端编号
+	ClientID string `gorm:"size:32;uniqueIndex:idx_oauth_consent"`
+	// 已授权范围，空格分隔
+	Scopes string `gorm:"size:1000"`
+	Audit
+}
+
+func (OAuthConsent) TableName() string {
+	return "oauth_consents"
+}
+
+// RedirectURIList 回调地址列表
+func (client *OAuthClient) RedirectURIList() []string {
+	return strings.Fields(client.RedirectURIs)
+}
+
+// ScopeList 允许申请的授权范围列表
+func (client *OAuthClient) ScopeList() []string {
+	return strings.Fields(client.Scopes)
+}
+
+// AllowRedirect 回调地址必须与注册的地址完全一致
+func (client *OAuthClient) AllowRedirect(uri string) bool {
+	for _, item := range client.RedirectURIList() {
+		if item == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// GetOAuthClient 用客户端编号获取客户端
+func (rep *MyDb) GetOAuthClient(clientID string) (client *OAuthClient, err error) {
+	err = rep.Where("client_id = ?", clientID).First(&client).Error
+	return
+}
+
+// GetOAuthClients 获取全部客户端
+func (rep *MyDb) GetOAuthClients() (array []*OAuthClient, err error) {
+	err = rep.Order("id desc").Find(&array).Error
+	return
+}
+
+// GetOAuthConsent 获取用户对客户端的授权记录
+func (rep *MyDb) GetOAuthConsent(userID uint, clientID string) (consent *OAuthConsent, err error) {
+	err = rep.Where("user_id = ? AND client_id = ?", userID, clientID).First(&consent).Error
+	return
+}
+
+// SaveOAuthConsent 保存用户对客户端的授权记录
+func (rep *MyDb) SaveOAuthConsent(userID uint, clientID, scopes string) error {
+	return rep.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scopes", "updated_at"}),
+	}).Create(&OAuthConsent{
+		UserID:   userID,
+		ClientID: clientID,
+		Scopes:   scopes,
+	}).Error
+}
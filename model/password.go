@@ -0,0 +1,34 @@
+package model
+
+// @Description 历史密码
+type PasswordHistory struct {
+	// 编号
+	ID uint `gorm:"primarykey"`
+	// 用户编号
+	UserID uint `gorm:"index"`
+	// 密码摘要
+	PasswordDigest string
+	Audit
+}
+
+// GetPasswordHistory 获取用户最近的历史密码
+func (rep *MyDb) GetPasswordHistory(userID uint, limit int) (array []*PasswordHistory, err error) {
+	err = rep.Where("user_id = ?", userID).Order("id desc").Limit(limit).Find(&array).Error
+	return
+}
+
+// AddPasswordHistory 记录历史密码，只保留最近keep条
+func (rep *MyDb) AddPasswordHistory(userID uint, digest string, keep int) error {
+	if err := rep.Create(&PasswordHistory{UserID: userID, PasswordDigest: digest}).Error; err != nil {
+		return err
+	}
+	var ids []uint
+	if err := rep.Model(&PasswordHistory{}).Where("user_id = ?", userID).
+		Order("id desc").Offset(keep).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return rep.Where("id IN ?", ids).Delete(&PasswordHistory{}).Error
+}
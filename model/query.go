@@ -0,0 +1,386 @@
+package model
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"singo/req"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidQuery 查询参数不在白名单内或格式错误
+var ErrInvalidQuery = errors.New("查询参数错误")
+
+const (
+	// MatchExact 精确匹配
+	MatchExact = "exact"
+	// MatchPrefix 前缀匹配
+	MatchPrefix = "prefix"
+	// MatchFuzzy 模糊匹配
+	MatchFuzzy = "fuzzy"
+)
+
+// idSortable 只按主键排序
+var idSortable = map[string]string{"id": "id"}
+
+// likeEscaper 转义LIKE通配符，避免用户输入的%和_参与匹配
+// 使用!作为转义符并显式声明ESCAPE，不依赖MySQL的默认转义符及sql_mode，SQLite下同样生效
+var likeEscaper = strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`)
+
+// likeClause 带转义声明的LIKE条件
+const likeClause = " LIKE ? ESCAPE '!'"
+
+// order 排序字段
+type order struct {
+	column string
+	desc   bool
+}
+
+// Query 列表查询构造器，将白名单内的查询参数转换为gorm条件
+// 列名只来自调用方代码中的白名单，用户输入一律作为占位符参数，不会拼接进SQL
+type Query struct {
+	scopes []func(*gorm.DB) *gorm.DB
+	orders []order
+	err    error
+}
+
+// NewQuery 创建查询构造器
+func NewQuery() *Query {
+	return &Query{}
+}
+
+// fail 记录第一个参数错误，之后的条件不再生效
+func (q *Query) fail(format string, args ...interface{}) *Query {
+	if q.err == nil {
+		q.err = fmt.Errorf("%w: %s", ErrInvalidQuery, fmt.Sprintf(format, args...))
+	}
+	return q
+}
+
+// Match 按匹配方式过滤字符串列，value为空时忽略
+func (q *Query) Match(column, value, mode string) *Query {
+	if value == "" {
+		return q
+	}
+	switch mode {
+	case "", MatchExact:
+		q.scopes = append(q.scopes, func(db *gorm.DB) *gorm.DB {
+			return db.Where(column+" = ?", value)
+		})
+	case MatchPrefix:
+		q.scopes = append(q.scopes, func(db *gorm.DB) *gorm.DB {
+			return db.Where(column+likeClause, likeEscaper.Replace(value)+"%")
+		})
+	case MatchFuzzy:
+		q.scopes = append(q.scopes, func(db *gorm.DB) *gorm.DB {
+			return db.Where(column+likeClause, "%"+likeEscaper.Replace(value)+"%")
+		})
+	default:
+		return q.fail("不支持的匹配方式%s", mode)
+	}
+	return q
+}
+
+// In 过滤列值在逗号分隔的取值中，取值必须在allowed内，values为空时忽略
+func (q *Query) In(column, values string, allowed ...string) *Query {
+	if values == "" {
+		return q
+	}
+	var items []string
+	for _, item := range strings.Split(values, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !contains(allowed, item) {
+			return q.fail("不支持的取值%s", item)
+		}
+		items = append(items, item)
+	}
+	if len(items) > 0 {
+		q.scopes = append(q.scopes, func(db *gorm.DB) *gorm.DB {
+			return db.Where(column+" IN ?", items)
+		})
+	}
+	return q
+}
+
+// Range 过滤时间列在[from, to]范围内，参数为毫秒时间戳，为0表示不限
+func (q *Query) Range(column string, from, to int64) *Query {
+	if from < 0 || to < 0 || (from > 0 && to > 0 && from > to) {
+		return q.fail("时间范围错误")
+	}
+	if from > 0 {
+		q.scopes = append(q.scopes, func(db *gorm.DB) *gorm.DB {
+			return db.Where(column+" >= ?", time.UnixMilli(from))
+		})
+	}
+	if to > 0 {
+		q.scopes = append(q.scopes, func(db *gorm.DB) *gorm.DB {
+			return db.Where(column+" <= ?", time.UnixMilli(to))
+		})
+	}
+	return q
+}
+
+// Sort 解析多字段排序参数，如 -created_at,user_name，字段前加"-"表示倒序
+// sortable为参数名到列名的白名单，最后总是追加主键排序，保证结果顺序稳定
+func (q *Query) Sort(sort string, sortable map[string]string) *Query {
+	seen := map[string]bool{}
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		desc := strings.HasPrefix(field, "-")
+		column, ok := sortable[strings.TrimPrefix(field, "-")]
+		if !ok {
+			return q.fail("不支持的排序字段%s", strings.TrimPrefix(field, "-"))
+		}
+		if seen[column] {
+			continue
+		}
+		seen[column] = true
+		q.orders = append(q.orders, order{column: column, desc: desc})
+	}
+	if !seen["id"] {
+		q.orders = append(q.orders, order{column: "id"})
+	}
+	return q
+}
+
+// Err 返回参数错误，错误可用errors.Is(err, ErrInvalidQuery)判断
+func (q *Query) Err() error {
+	return q.err
+}
+
+// Equal 过滤列等于value
+func (q *Query) Equal(column string, value interface{}) *Query {
+	q.scopes = append(q.scopes, func(db *gorm.DB) *gorm.DB {
+		return db.Where(column+" = ?", value)
+	})
+	return q
+}
+
+// Find 按分页参数查询到dest，dest为结构体指针切片的指针
+// 页码分页返回总数；游标分页按排序字段做keyset查询，返回下一页游标，不再统计总数
+func (q *Query) Find(db *gorm.DB, page *req.PageReq, dest interface{}) (total int64, next string, err error) {
+	if len(q.orders) == 0 {
+		q.Sort("", nil)
+	}
+	if err = q.err; err != nil {
+		return 0, "", err
+	}
+	// 会话内的每次链式调用都会复制条件，统计与查询互不影响
+	db = db.Session(&gorm.Session{})
+
+	if !page.CursorMode() {
+		if err = db.Model(dest).Scopes(q.scopes...).Count(&total).Error; err != nil {
+			return 0, "", err
+		}
+		err = q.order(db.Scopes(q.scopes...)).Offset(page.Offset()).Limit(page.Size()).Find(dest).Error
+		return total, "", err
+	}
+
+	if page.Page > 1 {
+		return 0, "", fmt.Errorf("%w: 游标分页不支持页码", ErrInvalidQuery)
+	}
+	query := db.Scopes(q.scopes...)
+	if page.Cursor != "" {
+		values, err := q.decodeCursor(page.Cursor)
+		if err != nil {
+			return 0, "", err
+		}
+		query = query.Where(q.after(values))
+	}
+	// 多查一条用于判断是否还有下一页
+	size := page.Size()
+	if err = q.order(query).Limit(size + 1).Find(dest).Error; err != nil {
+		return 0, "", err
+	}
+	rows := reflect.ValueOf(dest).Elem()
+	if rows.Len() > size {
+		rows.Set(rows.Slice(0, size))
+		next, err = q.encodeCursor(db, rows.Index(size-1).Interface())
+	}
+	return 0, next, err
+}
+
+// order 应用排序
+func (q *Query) order(db *gorm.DB) *gorm.DB {
+	for _, o := range q.orders {
+		if o.desc {
+			db = db.Order(o.column + " DESC")
+		} else {
+			db = db.Order(o.column)
+		}
+	}
+	return db
+}
+
+// sortKey 排序方式的规范表示，用于校验游标与当前排序是否一致
+func (q *Query) sortKey() string {
+	fields := make([]string, 0, len(q.orders))
+	for _, o := range q.orders {
+		if o.desc {
+			fields = append(fields, "-"+o.column)
+		} else {
+			fields = append(fields, o.column)
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// after 生成排在游标之后的keyset条件，如 (a > ?) OR (a = ? AND id > ?)
+// 游标值为nil表示NULL，按MySQL及SQLite的规则NULL在正序时排在最前，倒序时排在最后
+func (q *Query) after(values []interface{}) clause.Expr {
+	var ors []string
+	var args []interface{}
+	for i, o := range q.orders {
+		var ands []string
+		var vars []interface{}
+		for j := 0; j < i; j++ {
+			if values[j] == nil {
+				ands = append(ands, q.orders[j].column+" IS NULL")
+			} else {
+				ands = append(ands, q.orders[j].column+" = ?")
+				vars = append(vars, values[j])
+			}
+		}
+		switch {
+		case values[i] == nil && o.desc:
+			// 倒序时NULL之后没有更小的值
+			continue
+		case values[i] == nil:
+			ands = append(ands, o.column+" IS NOT NULL")
+		case o.desc:
+			ands = append(ands, "("+o.column+" < ? OR "+o.column+" IS NULL)")
+			vars = append(vars, values[i])
+		default:
+			ands = append(ands, o.column+" > ?")
+			vars = append(vars, values[i])
+		}
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+		args = append(args, vars...)
+	}
+	if len(ors) == 0 {
+		return clause.Expr{SQL: "1 = 0"}
+	}
+	return clause.Expr{SQL: "(" + strings.Join(ors, " OR ") + ")", Vars: args}
+}
+
+// cursor 游标内容，记录排序方式及上一页最后一行的排序字段值
+type cursor struct {
+	// 排序方式
+	Sort string `json:"s"`
+	// 排序字段值，NULL为null
+	Values []interface{} `json:"v"`
+	// 时间类型的字段下标，时间以RFC3339格式保存
+	Times []int `json:"t,omitempty"`
+}
+
+// encodeCursor 用行的排序字段值生成游标
+func (q *Query) encodeCursor(db *gorm.DB, row interface{}) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(row); err != nil {
+		return "", err
+	}
+	value := reflect.Indirect(reflect.ValueOf(row))
+	c := cursor{Sort: q.sortKey()}
+	for i, o := range q.orders {
+		field := stmt.Schema.LookUpField(o.column)
+		if field == nil {
+			return "", fmt.Errorf("排序字段%s不存在", o.column)
+		}
+		v, _ := field.ValueOf(db.Statement.Context, value)
+		if valuer, ok := v.(driver.Valuer); ok {
+			var err error
+			if v, err = valuer.Value(); err != nil {
+				return "", err
+			}
+		}
+		if t, ok := v.(*time.Time); ok {
+			if t == nil {
+				v = nil
+			} else {
+				v = *t
+			}
+		}
+		if t, ok := v.(time.Time); ok {
+			// 非指针的时间字段读取到NULL时为零值，同样按NULL处理
+			if t.IsZero() {
+				v = nil
+			} else {
+				v = t.UTC().Format(time.RFC3339Nano)
+				c.Times = append(c.Times, i)
+			}
+		}
+		c.Values = append(c.Values, v)
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor 解析游标，游标须由相同排序方式生成
+func (q *Query) decodeCursor(s string) ([]interface{}, error) {
+	invalid := fmt.Errorf("%w: 游标无效", ErrInvalidQuery)
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+	var c cursor
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&c); err != nil {
+		return nil, invalid
+	}
+	if c.Sort != q.sortKey() || len(c.Values) != len(q.orders) {
+		return nil, fmt.Errorf("%w: 游标与排序方式不匹配", ErrInvalidQuery)
+	}
+	for i, v := range c.Values {
+		n, ok := v.(json.Number)
+		if !ok {
+			continue
+		}
+		if c.Values[i], err = n.Int64(); err != nil {
+			if c.Values[i], err = n.Float64(); err != nil {
+				return nil, invalid
+			}
+		}
+	}
+	for _, i := range c.Times {
+		if i < 0 || i >= len(c.Values) {
+			return nil, invalid
+		}
+		str, ok := c.Values[i].(string)
+		if !ok {
+			return nil, invalid
+		}
+		t, err := time.Parse(time.RFC3339Nano, str)
+		if err != nil {
+			return nil, invalid
+		}
+		c.Values[i] = t.Local()
+	}
+	return c.Values, nil
+}
+
+func contains(items []string, item string) bool {
+	for _, s := range items {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
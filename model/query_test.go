@@ -0,0 +1,124 @@
+package model_test
+
+import (
+	"fmt"
+	"reflect"
+	"singo/model"
+	"singo/req"
+	"singo/testutil"
+	"testing"
+	"time"
+)
+
+// collectIDs 按游标逐页查询全部用户编号
+func collectIDs(t *testing.T, rep *model.MyDb, sort string, limit int) []uint {
+	t.Helper()
+	var ids []uint
+	param := &req.PageUserReq{PageReq: req.PageReq{Limit: limit}, SortReq: req.SortReq{Sort: sort}}
+	for i := 0; i < 20; i++ {
+		_, next, users, err := rep.GetUsers(param)
+		if err != nil {
+			t.Fatalf("GetUsers(%s) error = %v", sort, err)
+		}
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+		if next == "" {
+			return ids
+		}
+		param.Cursor = next
+	}
+	t.Fatalf("GetUsers(%s) 游标分页未结束", sort)
+	return nil
+}
+
+func TestCursorNullableTime(t *testing.T) {
+	db := testutil.SetupDB(t)
+	rep := model.GetDbClient()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	for i := 0; i < 7; i++ {
+		user := &model.User{UserName: fmt.Sprintf("user%02d", i), Status: model.Active}
+		// 相同的创建时间用于验证按主键排序
+		user.CreatedAt = base.Add(time.Duration(i/2) * time.Hour)
+		if err := db.Create(user).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 迁移前的旧数据创建时间为NULL
+	if err := db.Exec("UPDATE users SET created_at = NULL WHERE id IN (2, 5)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sort := range []string{"created_at", "-created_at", "status,-created_at"} {
+		param := &req.PageUserReq{PageReq: req.PageReq{PageSize: 100}, SortReq: req.SortReq{Sort: sort}}
+		_, _, all, err := rep.GetUsers(param)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := make([]uint, 0, len(all))
+		for _, user := range all {
+			want = append(want, user.ID)
+		}
+		for _, limit := range []int{1, 2, 3} {
+			if got := collectIDs(t, rep, sort, limit); !reflect.DeepEqual(got, want) {
+				t.Errorf("sort=%s limit=%d 游标分页 = %v, want %v", sort, limit, got, want)
+			}
+		}
+	}
+}
+
+func TestCursorMismatch(t *testing.T) {
+	testutil.SetupDB(t)
+	rep := model.GetDbClient()
+	for i := 0; i < 3; i++ {
+		if err := rep.Create(&model.User{UserName: fmt.Sprintf("user%02d", i)}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, next, _, err := rep.GetUsers(&req.PageUserReq{PageReq: req.PageReq{Limit: 1}, SortReq: req.SortReq{Sort: "created_at"}})
+	if err != nil || next == "" {
+		t.Fatalf("GetUsers() next = %q, error = %v", next, err)
+	}
+	// 游标不能用于其他排序方式
+	_, _, _, err = rep.GetUsers(&req.PageUserReq{PageReq: req.PageReq{Cursor: next}, SortReq: req.SortReq{Sort: "-created_at"}})
+	if err == nil {
+		t.Error("排序方式不同时应拒绝游标")
+	}
+	if _, _, _, err = rep.GetUsers(&req.PageUserReq{PageReq: req.PageReq{Cursor: "invalid"}}); err == nil {
+		t.Error("应拒绝无效游标")
+	}
+}
+
+func TestMatchEscapesWildcards(t *testing.T) {
+	db := testutil.SetupDB(t)
+	rep := model.GetDbClient()
+	for _, name := range []string{"ab%cd", "abxcd", "a_b01", "axb01", "a!b01"} {
+		if err := db.Create(&model.User{UserName: name, Status: model.Active}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		value, match string
+		want         []string
+	}{
+		{"ab%", model.MatchPrefix, []string{"ab%cd"}},
+		{"%c", model.MatchFuzzy, []string{"ab%cd"}},
+		{"a_b", model.MatchPrefix, []string{"a_b01"}},
+		{"!b", model.MatchFuzzy, []string{"a!b01"}},
+	}
+	for _, tt := range tests {
+		param := &req.PageUserReq{PageReq: req.PageReq{PageSize: 100}, UserName: tt.value, Match: tt.match}
+		_, _, users, err := rep.GetUsers(param)
+		if err != nil {
+			t.Fatalf("GetUsers(%s, %s) error = %v", tt.value, tt.match, err)
+		}
+		var got []string
+		for _, user := range users {
+			got = append(got, user.UserName)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetUsers(%s, %s) = %v, want %v", tt.value, tt.match, got, tt.want)
+		}
+	}
+}
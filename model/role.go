@@ -0,0 +1,141 @@
+package model
+
+// @Description 角色模型
+type Role struct {
+	// 编号
+	ID uint `gorm:"primarykey"`
+	// 角色名
+	Name string `gorm:"uniqueIndex;size:50"`
+	// 描述
+	Description string
+	// 权限
+	Permissions []*Permission `gorm:"many2many:role_permissions"`
+	Audit
+}
+
+// @Description 权限模型
+type Permission struct {
+	// 编号
+	ID uint `gorm:"primarykey"`
+	// 权限编码
+	Code string `gorm:"uniqueIndex;size:100"`
+	// 描述
+	Description string
+	Audit
+}
+
+const (
+	// AdminRole 超级管理员角色，拥有全部内置权限
+	AdminRole = "admin"
+	// PermUserList 查看用户列表
+	PermUserList = "user:list"
+	// PermUserRevoke 强制用户下线
+	PermUserRevoke = "user:revoke"
+	// PermUserUnlock 解除账号登录锁定
+	PermUserUnlock = "user:unlock"
+	// PermUserModerate 封禁、解封及删除用户
+	PermUserModerate = "user:moderate"
+	// PermUserImpersonate 模拟用户登录
+	PermUserImpersonate = "user:impersonate"
+	// PermAuditView 查看审计日志
+	PermAuditView = "audit:view"
+	// PermOAuthManage 管理OAuth2客户端
+	PermOAuthManage = "oauth:manage"
+	// PermAppManage 管理开放平台应用
+	PermAppManage = "app:manage"
+	// PermRoleManage 管理角色及分配
+	PermRoleManage = "role:manage"
+)
+
+// BuiltinPermissions 内置权限，迁移时自动创建
+var BuiltinPermissions = []*Permission{
+	{Code: PermUserList, Description: "查看用户列表"},
+	{Code: PermUserRevoke, Description: "强制用户下线"},
+	{Code: PermUserUnlock, Description: "解除账号登录锁定"},
+	{Code: PermUserModerate, Description: "封禁、解封及删除用户"},
+	{Code: PermUserImpersonate, Description: "模拟用户登录"},
+	{Code: PermAuditView, Description: "查看审计日志"},
+	{Code: PermOAuthManage, Description: "管理OAuth2客户端"},
+	{Code: PermAppManage, Description: "管理开放平台应用"},
+	{Code: PermRoleManage, Description: "管理角色及分配"},
+}
+
+// RoleNames 用户拥有的角色名
+func (user *User) RoleNames() []string {
+	names := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+// GetRoles 获取全部角色
+func (rep *MyDb) GetRoles() (array []*Role, err error) {
+	err = rep.Preload("Permissions").Find(&array).Error
+	return
+}
+
+// GetRole 用ID获取角色
+func (rep *MyDb) GetRole(id uint) (role *Role, err error) {
+	err = rep.Preload("Permissions").First(&role, id).Error
+	return
+}
+
+// GetRolesByNames 按角色名批量获取角色
+func (rep *MyDb) GetRolesByNames(names []string) (array []*Role, err error) {
+	err = rep.Where("name IN ?", names).Find(&array).Error
+	return
+}
+
+// GetPermissions 获取全部权限
+func (rep *MyDb) GetPermissions() (array []*Permission, err error) {
+	err = rep.Find(&array).Error
+	return
+}
+
+// GetPermissionsByCodes 按权限编码批量获取权限
+func (rep *MyDb) GetPermissionsByCodes(codes []string) (array []*Permission, err error) {
+	err = rep.Where("code IN ?", codes).Find(&array).Error
+	return
+}
+
+// HasPermission 判断角色中是否有任意一个拥有指定权限
+func (rep *MyDb) HasPermission(roles []string, code string) (bool, error) {
+	if len(roles) == 0 {
+		return false, nil
+	}
+	var count int64
+	err := rep.Model(&Permission{}).
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.name IN ? AND permissions.code = ?", roles, code).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// seedRoles 创建内置权限和管理员角色，并为配置中的管理员分配角色
+func seedRoles() {
+	for _, perm := range BuiltinPermissions {
+		if err := DbClient.Where(Permission{Code: perm.Code}).Attrs(Permission{Description: perm.Description}).FirstOrCreate(perm).Error; err != nil {
+			panic(err)
+		}
+	}
+
+	admin := Role{Name: AdminRole}
+	if err := DbClient.Where(admin).Attrs(Role{Description: "超级管理员"}).FirstOrCreate(&admin).Error; err != nil {
+		panic(err)
+	}
+	if err := DbClient.Model(&admin).Association("Permissions").Append(BuiltinPermissions); err != nil {
+		panic(err)
+	}
+
+	for _, username := range config.Server.Admins {
+		var user User
+		if err := DbClient.Where("user_name = ?", username).First(&user).Error; err != nil {
+			continue
+		}
+		if err := DbClient.Model(&user).Association("Roles").Append(&admin); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package model
 
 import (
-	"golang.org/x/crypto/bcrypt"
+	"fmt"
+	"gorm.io/gorm"
+	"singo/hasher"
 	"singo/req"
+	"time"
 )
 
 // @Description 用户模型
@@ -13,17 +16,34 @@ type User struct {
 	UserName string
 	// 密码加密
 	PasswordDigest string
+	// 邮箱
+	Email string `gorm:"size:100;index"`
+	// 手机号，未绑定时为空
+	Phone *string `gorm:"size:20;uniqueIndex"`
 	// 昵称
 	Nickname string
 	// 状态
 	Status string
 	// 头像
 	Avatar string `gorm:"size:1000"`
+	// 封禁原因
+	SuspendReason string
+	// 封禁到期时间，为空表示永久封禁
+	SuspendedUntil *time.Time
+	// 是否开启两步验证
+	MfaEnabled bool
+	// 加密后的两步验证密钥
+	MfaSecret string `json:"-"`
+	// 删除时间
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	// 个人信息清除时间
+	PurgedAt *time.Time
+	// 角色
+	Roles []*Role `gorm:"many2many:user_roles"`
+	Audit
 }
 
 const (
-	// PassWordCost 密码加密难度
-	PassWordCost = 12
 	// Active 激活用户
 	Active string = "active"
 	// Inactive 未激活用户
@@ -34,40 +54,121 @@ const (
 
 // GetUser 用ID获取用户
 func (rep *MyDb) GetUser(username string) (user *User, err error) {
-	err = rep.Where("user_name = ?", username).First(&user).Error
+	err = rep.Preload("Roles").Where("user_name = ?", username).First(&user).Error
 	return
 }
 
-// SetPassword 设置密码
+// GetUserByEmail 用邮箱获取用户
+func (rep *MyDb) GetUserByEmail(email string) (user *User, err error) {
+	err = rep.Where("email = ?", email).First(&user).Error
+	return
+}
+
+// GetUserByPhone 用手机号获取用户
+func (rep *MyDb) GetUserByPhone(phone string) (user *User, err error) {
+	err = rep.Preload("Roles").Where("phone = ?", phone).First(&user).Error
+	return
+}
+
+// GetPendingDeletionUser 获取注销宽限期内(已删除且尚未清除个人信息)的用户
+func (rep *MyDb) GetPendingDeletionUser(query string, args ...interface{}) (user *User, err error) {
+	err = rep.Unscoped().Preload("Roles").Where("deleted_at IS NOT NULL AND purged_at IS NULL").
+		Where(query, args...).First(&user).Error
+	return
+}
+
+// RestoreUser 撤销注销，恢复宽限期内已删除的用户
+func (rep *MyDb) RestoreUser(user *User) error {
+	user.DeletedAt = gorm.DeletedAt{}
+	return rep.Unscoped().Model(user).Update("deleted_at", nil).Error
+}
+
+// GetUsersToPurge 获取删除时间早于before且尚未清除个人信息的用户
+func (rep *MyDb) GetUsersToPurge(before time.Time, limit int) (array []*User, err error) {
+	err = rep.Unscoped().Where("deleted_at < ? AND purged_at IS NULL", before).Limit(limit).Find(&array).Error
+	return
+}
+
+// PurgeUser 清除已删除用户的个人信息及关联数据，保留匿名化后的用户记录
+func (rep *MyDb) PurgeUser(user *User) error {
+	return rep.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []interface{}{
+			&RecoveryCode{}, &ApiKey{}, &Identity{}, &OAuthConsent{}, &PasswordHistory{}, &LoginHistory{},
+		} {
+			if err := tx.Where("user_id = ?", user.ID).Delete(table).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(user).Association("Roles").Clear(); err != nil {
+			return err
+		}
+		now := time.Now()
+		return tx.Unscoped().Model(user).Updates(map[string]interface{}{
+			"user_name":       fmt.Sprintf("deleted_%d", user.ID),
+			"password_digest": "",
+			"email":           "",
+			"phone":           nil,
+			"nickname":        fmt.Sprintf("已注销用户%d", user.ID),
+			"avatar":          "",
+			"suspend_reason":  "",
+			"mfa_enabled":     false,
+			"mfa_secret":      "",
+			"purged_at":       &now,
+		}).Error
+	})
+}
+
+// PhoneNumber 获取手机号，未绑定时返回空字符串
+func (user *User) PhoneNumber() string {
+	if user.Phone == nil {
+		return ""
+	}
+	return *user.Phone
+}
+
+// SuspendExpired 判断限时封禁是否已到期
+func (user *User) SuspendExpired() bool {
+	return user.Status == Suspend && user.SuspendedUntil != nil && user.SuspendedUntil.Before(time.Now())
+}
+
+// SetPassword 使用当前配置的算法设置密码
 func (user *User) SetPassword(password string) error {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), PassWordCost)
+	digest, err := hasher.Hash(password)
 	if err != nil {
 		return err
 	}
-	user.PasswordDigest = string(bytes)
+	user.PasswordDigest = digest
 	return nil
 }
 
 // CheckPassword 校验密码
 func (user *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(user.PasswordDigest), []byte(password))
-	return err == nil
+	return hasher.Verify(password, user.PasswordDigest)
 }
 
-func (rep *MyDb) GetUsers(param *req.PageUserReq) (total int64, array []*User, err error) {
-	if param.UserName != "" {
-		rep.DB = rep.DB.Where("user_name = ?", param.UserName)
-	}
-
-	// 查询总数
-	if err = rep.Model(&User{}).Count(&total).Error; err != nil {
-		return 0, nil, err
-	}
+// PasswordNeedsRehash 判断密码摘要是否需要按当前配置重新生成
+func (user *User) PasswordNeedsRehash() bool {
+	return hasher.NeedsRehash(user.PasswordDigest)
+}
 
-	// 分页查询
-	if err = rep.Offset(param.Offset()).Limit(param.PageSize).Find(&array).Error; err != nil {
-		return 0, nil, err
-	}
+// userSortable 用户列表允许排序的字段
+var userSortable = map[string]string{
+	"id":         "id",
+	"user_name":  "user_name",
+	"nickname":   "nickname",
+	"status":     "status",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
 
+// GetUsers 按过滤条件及排序分页查询用户，游标分页时返回下一页游标
+func (rep *MyDb) GetUsers(param *req.PageUserReq) (total int64, next string, array []*User, err error) {
+	total, next, err = NewQuery().
+		Match("user_name", param.UserName, param.Match).
+		Match("nickname", param.Nickname, param.Match).
+		In("status", param.Status, Active, Inactive, Suspend).
+		Range("created_at", param.CreatedFrom, param.CreatedTo).
+		Sort(param.Sort, userSortable).
+		Find(rep.DB, &param.PageReq, &array)
 	return
 }
@@ -0,0 +1,160 @@
+// Package oidctest 提供本地模拟的OpenID Connect身份提供方，用于测试外部登录
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Issuer 模拟的身份提供方，提供discovery、JWKS及令牌接口
+type Issuer struct {
+	*httptest.Server
+	// ClientID 登记的客户端编号
+	ClientID string
+	// ClientSecret 登记的客户端密钥
+	ClientSecret string
+	// Kid 签名密钥编号
+	Kid string
+	// Key 签名私钥
+	Key *rsa.PrivateKey
+
+	mu       sync.Mutex
+	idToken  string
+	lastForm url.Values
+}
+
+// NewIssuer 启动模拟的身份提供方，使用完毕后需调用Close
+func NewIssuer(clientID, clientSecret string) *Issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	issuer := &Issuer{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Kid:          "test-key",
+		Key:          key,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("/jwks", issuer.jwks)
+	mux.HandleFunc("/token", issuer.token)
+	issuer.Server = httptest.NewServer(mux)
+	return issuer
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 i.URL,
+		"authorization_endpoint": i.URL + "/authorize",
+		"token_endpoint":         i.URL + "/token",
+		"jwks_uri":               i.URL + "/jwks",
+	})
+}
+
+func (i *Issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := i.Key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": i.Kid,
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientID, secret, _ := r.BasicAuth()
+	clientID, _ = url.QueryUnescape(clientID)
+	secret, _ = url.QueryUnescape(secret)
+	if clientID != i.ClientID || secret != i.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	i.mu.Lock()
+	i.lastForm = r.PostForm
+	idToken := i.idToken
+	i.idToken = ""
+	i.mu.Unlock()
+
+	if r.PostForm.Get("grant_type") != "authorization_code" || idToken == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error":             "invalid_grant",
+			"error_description": "授权码无效",
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": "mock-access-token",
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+// Claims 生成有效的ID Token声明，可在签名前修改
+func (i *Issuer) Claims(subject, nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":   i.URL,
+		"aud":   i.ClientID,
+		"sub":   subject,
+		"nonce": nonce,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+	}
+}
+
+// Sign 使用签名私钥签发ID Token
+func (i *Issuer) Sign(claims jwt.MapClaims) string {
+	return i.SignWith(i.Key, claims)
+}
+
+// SignWith 使用指定私钥签发ID Token，kid不变，用于构造签名错误的Token
+func (i *Issuer) SignWith(key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = i.Kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+// SetIDToken 设置令牌接口下一次返回的ID Token，只生效一次
+func (i *Issuer) SetIDToken(idToken string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.idToken = idToken
+}
+
+// LastForm 最近一次令牌请求的表单参数
+func (i *Issuer) LastForm() url.Values {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.lastForm
+}
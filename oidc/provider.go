@@ -0,0 +1,174 @@
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"singo/conf"
+	"strings"
+	"sync"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// discovery OpenID Provider配置，见OpenID Connect Discovery 1.0
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// Provider 外部OpenID Connect身份提供方
+type Provider struct {
+	cfg conf.OidcProviderConfig
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      *keySet
+}
+
+var providers map[string]*Provider
+var providersOnce sync.Once
+var providersMu sync.RWMutex
+
+func loadProviders() {
+	providersOnce.Do(func() {
+		providers = map[string]*Provider{}
+		for _, cfg := range conf.GetConfig().Oidc.Providers {
+			providers[cfg.Name] = &Provider{cfg: cfg}
+		}
+	})
+}
+
+// GetProvider 根据名称获取配置的身份提供方
+func GetProvider(name string) (*Provider, bool) {
+	loadProviders()
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[name]
+	return p, ok
+}
+
+// RegisterProvider 注册身份提供方，与配置中的提供方同名时覆盖，测试时用于接入模拟的身份提供方
+func RegisterProvider(cfg conf.OidcProviderConfig) *Provider {
+	loadProviders()
+	p := &Provider{cfg: cfg}
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[cfg.Name] = p
+	return p
+}
+
+// Name 身份提供方名称
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+func getJSON(rawURL string, v interface{}) error {
+	resp, err := httpClient.Get(rawURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// getDiscovery 获取并缓存Provider配置
+func (p *Provider) getDiscovery() (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var d discovery
+	if err := getJSON(strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, err
+	}
+	if d.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("issuer不匹配: %s", d.Issuer)
+	}
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+// AuthURL 生成跳转到身份提供方的授权地址
+func (p *Provider) AuthURL(state, nonce, codeChallenge string) (string, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+	values := url.Values{}
+	values.Set("response_type", "code")
+	values.Set("client_id", p.cfg.ClientID)
+	values.Set("redirect_uri", p.cfg.RedirectURL)
+	values.Set("scope", strings.Join(scopes, " "))
+	values.Set("state", state)
+	values.Set("nonce", nonce)
+	values.Set("code_challenge", codeChallenge)
+	values.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + values.Encode(), nil
+}
+
+// tokenResponse 授权码换取Token的响应
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange 使用授权码换取ID Token并校验，返回身份声明
+func (p *Provider) Exchange(code, codeVerifier, nonce string) (*Claims, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	values.Set("grant_type", "authorization_code")
+	values.Set("code", code)
+	values.Set("redirect_uri", p.cfg.RedirectURL)
+	values.Set("code_verifier", codeVerifier)
+	req, err := http.NewRequest(http.MethodPost, d.TokenEndpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("%s: %s", token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("响应中缺少id_token")
+	}
+	return p.verifyIDToken(token.IDToken, nonce)
+}
@@ -0,0 +1,187 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"singo/conf"
+	"singo/oidc/oidctest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Issuer) {
+	issuer := oidctest.NewIssuer("client-1", "secret-1")
+	t.Cleanup(issuer.Close)
+	p := &Provider{cfg: conf.OidcProviderConfig{
+		Name:         "mock",
+		Issuer:       issuer.URL,
+		ClientID:     "client-1",
+		ClientSecret: "secret-1",
+		RedirectURL:  "http://localhost/callback",
+	}}
+	return p, issuer
+}
+
+func TestExchange(t *testing.T) {
+	p, issuer := newTestProvider(t)
+
+	claims := issuer.Claims("user-1", "nonce-1")
+	claims["email"] = "a@example.com"
+	claims["email_verified"] = true
+	claims["name"] = "Alice"
+	issuer.SetIDToken(issuer.Sign(claims))
+
+	result, err := p.Exchange("code-1", "verifier-1", "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if result.Subject != "user-1" || result.Email != "a@example.com" || !result.EmailVerified || result.Name != "Alice" {
+		t.Errorf("Exchange() = %+v", result)
+	}
+
+	form := issuer.LastForm()
+	if form.Get("code") != "code-1" || form.Get("code_verifier") != "verifier-1" || form.Get("redirect_uri") != "http://localhost/callback" {
+		t.Errorf("令牌请求参数错误 %v", form)
+	}
+}
+
+func TestExchangeError(t *testing.T) {
+	p, _ := newTestProvider(t)
+
+	// 未设置ID Token时令牌接口返回invalid_grant
+	if _, err := p.Exchange("code-1", "verifier-1", "nonce-1"); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("Exchange() error = %v, want invalid_grant", err)
+	}
+}
+
+func TestAuthURL(t *testing.T) {
+	p, issuer := newTestProvider(t)
+
+	authURL, err := p.AuthURL("state-1", "nonce-1", "challenge-1")
+	if err != nil {
+		t.Fatalf("AuthURL() error = %v", err)
+	}
+	if !strings.HasPrefix(authURL, issuer.URL+"/authorize?") {
+		t.Errorf("AuthURL() = %s", authURL)
+	}
+	for _, param := range []string{"state=state-1", "nonce=nonce-1", "code_challenge=challenge-1", "code_challenge_method=S256"} {
+		if !strings.Contains(authURL, param) {
+			t.Errorf("AuthURL() = %s, missing %s", authURL, param)
+		}
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	p, issuer := newTestProvider(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   func() string
+		nonce   string
+		wantErr bool
+	}{
+		{
+			name:  "有效Token",
+			token: func() string { return issuer.Sign(issuer.Claims("user-1", "nonce-1")) },
+			nonce: "nonce-1",
+		},
+		{
+			name: "aud为数组",
+			token: func() string {
+				claims := issuer.Claims("user-1", "nonce-1")
+				claims["aud"] = []string{"other", "client-1"}
+				return issuer.Sign(claims)
+			},
+			nonce: "nonce-1",
+		},
+		{
+			name:    "签名错误",
+			token:   func() string { return issuer.SignWith(otherKey, issuer.Claims("user-1", "nonce-1")) },
+			nonce:   "nonce-1",
+			wantErr: true,
+		},
+		{
+			name: "签发方错误",
+			token: func() string {
+				claims := issuer.Claims("user-1", "nonce-1")
+				claims["iss"] = "https://evil.example.com"
+				return issuer.Sign(claims)
+			},
+			nonce:   "nonce-1",
+			wantErr: true,
+		},
+		{
+			name: "受众错误",
+			token: func() string {
+				claims := issuer.Claims("user-1", "nonce-1")
+				claims["aud"] = "client-2"
+				return issuer.Sign(claims)
+			},
+			nonce:   "nonce-1",
+			wantErr: true,
+		},
+		{
+			name: "已过期",
+			token: func() string {
+				claims := issuer.Claims("user-1", "nonce-1")
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return issuer.Sign(claims)
+			},
+			nonce:   "nonce-1",
+			wantErr: true,
+		},
+		{
+			name: "缺少exp",
+			token: func() string {
+				claims := issuer.Claims("user-1", "nonce-1")
+				delete(claims, "exp")
+				return issuer.Sign(claims)
+			},
+			nonce:   "nonce-1",
+			wantErr: true,
+		},
+		{
+			name:    "nonce不匹配",
+			token:   func() string { return issuer.Sign(issuer.Claims("user-1", "nonce-1")) },
+			nonce:   "nonce-2",
+			wantErr: true,
+		},
+		{
+			name: "缺少sub",
+			token: func() string {
+				return issuer.Sign(issuer.Claims("", "nonce-1"))
+			},
+			nonce:   "nonce-1",
+			wantErr: true,
+		},
+		{
+			name: "HS256签名",
+			token: func() string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, issuer.Claims("user-1", "nonce-1"))
+				token.Header["kid"] = issuer.Kid
+				signed, _ := token.SignedString([]byte("secret"))
+				return signed
+			},
+			nonce:   "nonce-1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := p.verifyIDToken(tt.token(), tt.nonce)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("verifyIDToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && claims.Subject != "user-1" {
+				t.Errorf("verifyIDToken() subject = %s", claims.Subject)
+			}
+		})
+	}
+}
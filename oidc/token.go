@@ -0,0 +1,194 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Claims ID Token中的身份声明
+type Claims struct {
+	// 身份提供方内的用户唯一标识
+	Subject string
+	// 邮箱
+	Email string
+	// 邮箱是否已验证
+	EmailVerified bool
+	// 名称
+	Name string
+	// 头像
+	Picture string
+}
+
+// jwk JSON Web Key，仅解析签名验证所需字段
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type keySet struct {
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// publicKey 将JWK转换为公钥，支持RSA及P-256/P-384椭圆曲线
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	decode := func(s string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(b), nil
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("不支持的曲线 %s", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型 %s", k.Kty)
+	}
+}
+
+// getKey 获取验证签名的公钥，kid未知时重新拉取JWKS(最多每分钟一次)
+func (p *Provider) getKey(kid string) (crypto.PublicKey, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.keys != nil {
+		if key, ok := p.keys.keys[kid]; ok {
+			return key, nil
+		}
+		if time.Since(p.keys.fetchedAt) < time.Minute {
+			return nil, fmt.Errorf("unknown kid %s", kid)
+		}
+	}
+
+	var set struct {
+		Keys []*jwk `json:"keys"`
+	}
+	if err = getJSON(d.JwksURI, &set); err != nil {
+		return nil, err
+	}
+	keys := &keySet{keys: map[string]crypto.PublicKey{}, fetchedAt: time.Now()}
+	for _, item := range set.Keys {
+		if key, err := item.publicKey(); err == nil {
+			keys.keys[item.Kid] = key
+		}
+	}
+	p.keys = keys
+
+	key, ok := keys.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %s", kid)
+	}
+	return key, nil
+}
+
+// verifyIDToken 校验ID Token签名、签发方、受众、有效期及nonce
+func (p *Provider) verifyIDToken(idToken, nonce string) (*Claims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.getKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		switch key.(type) {
+		case *rsa.PublicKey:
+			if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+				return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+			}
+		case *ecdsa.PublicKey:
+			if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
+				return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+			}
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if iss, _ := claims["iss"].(string); iss != p.cfg.Issuer {
+		return nil, errors.New("issuer不匹配")
+	}
+	if !hasAudience(claims["aud"], p.cfg.ClientID) {
+		return nil, errors.New("audience不匹配")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, errors.New("缺少exp")
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, errors.New("nonce不匹配")
+	}
+
+	result := &Claims{}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.EmailVerified, _ = claims["email_verified"].(bool)
+	result.Name, _ = claims["name"].(string)
+	result.Picture, _ = claims["picture"].(string)
+	if result.Subject == "" {
+		return nil, errors.New("缺少sub")
+	}
+	return result, nil
+}
+
+// hasAudience aud可以是字符串或字符串数组
+func hasAudience(aud interface{}, clientID string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == clientID
+	case []interface{}:
+		for _, item := range v {
+			if s, _ := item.(string); s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package policy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"singo/conf"
+	"singo/logger"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 密码不符合要求的原因编码
+const (
+	ReasonTooShort        = "too_short"
+	ReasonCharClasses     = "char_classes"
+	ReasonRepeatedChars   = "repeated_chars"
+	ReasonContainUserInfo = "contains_user_info"
+	ReasonBreached        = "breached"
+	ReasonReused          = "reused"
+)
+
+// Reason 密码不符合要求的原因
+type Reason struct {
+	// 原因编码
+	Code string `json:"code"`
+	// 原因描述
+	Message string `json:"message"`
+}
+
+// CheckPassword 按配置的密码策略检查密码，返回全部不符合要求的原因
+// userInfo为用户名、昵称等不允许出现在密码中的信息
+func CheckPassword(password string, userInfo ...string) []Reason {
+	cfg := conf.GetConfig().Password
+	var reasons []Reason
+
+	if utf8.RuneCountInString(password) < cfg.MinLength {
+		reasons = append(reasons, Reason{ReasonTooShort, fmt.Sprintf("密码长度不能少于%d位", cfg.MinLength)})
+	}
+	if classes := charClasses(password); classes < cfg.MinClasses {
+		reasons = append(reasons, Reason{ReasonCharClasses,
+			fmt.Sprintf("密码需包含大写字母、小写字母、数字、特殊字符中的至少%d种", cfg.MinClasses)})
+	}
+	if cfg.MaxRepeat > 0 && maxRepeat(password) > cfg.MaxRepeat {
+		reasons = append(reasons, Reason{ReasonRepeatedChars, fmt.Sprintf("同一字符不能连续出现超过%d次", cfg.MaxRepeat)})
+	}
+	if cfg.DisallowUserInfo && containsUserInfo(password, userInfo) {
+		reasons = append(reasons, Reason{ReasonContainUserInfo, "密码不能包含用户名或昵称"})
+	}
+
+	breached, err := Breached(password)
+	if err != nil {
+		logger.Error("查询泄露密码库错误", err)
+		return append(reasons, Reason{ReasonBreached, "暂时无法校验密码安全性，请稍后再试"})
+	}
+	if breached {
+		reasons = append(reasons, Reason{ReasonBreached, "该密码已在公开的泄露数据中出现，请更换"})
+	}
+	return reasons
+}
+
+// Breached 在离线泄露密码库中查询密码
+// 密码库按SHA-1前5位分文件存放，每行为"剩余35位摘要:出现次数"，与k-匿名查询接口的返回格式一致
+func Breached(password string) (bool, error) {
+	dir := conf.GetConfig().Password.BreachedDir
+	if dir == "" {
+		return false, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:5], digest[5:]
+
+	f, err := os.Open(filepath.Join(dir, prefix))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		hash, _, _ := strings.Cut(line, ":")
+		if strings.EqualFold(hash, suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// charClasses 统计密码包含的字符种类
+func charClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+// maxRepeat 计算同一字符连续出现的最大次数
+func maxRepeat(password string) int {
+	var max, count int
+	var last rune
+	for i, r := range []rune(password) {
+		if i > 0 && r == last {
+			count++
+		} else {
+			count = 1
+		}
+		last = r
+		if count > max {
+			max = count
+		}
+	}
+	return max
+}
+
+// containsUserInfo 判断密码是否包含用户信息，忽略大小写，过短的信息不做判断
+func containsUserInfo(password string, userInfo []string) bool {
+	lower := strings.ToLower(password)
+	for _, info := range userInfo {
+		if utf8.RuneCountInString(info) < 3 {
+			continue
+		}
+		if strings.Contains(lower, strings.ToLower(info)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,9 @@
+package req
+
+// Client 请求来源信息
+type Client struct {
+	// 客户端IP
+	IP string
+	// 客户端UA
+	UserAgent string
+}
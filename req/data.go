@@ -1,20 +1,71 @@
 package req
 
-// @Description 分页查询结构
+const (
+	// DefaultPageSize 未指定时的每页大小
+	DefaultPageSize = 20
+	// MaxPageSize 每页大小上限
+	MaxPageSize = 100
+)
+
+// @Description 分页查询结构，支持页码分页及游标分页，传入cursor或limit时使用游标分页
 type PageReq struct {
-	// 页码
-	Page int `json:"page" form:"page"`
+	// 页码，从1开始
+	Page int `json:"page" form:"page" binding:"omitempty,min=1"`
 	// 每页大小
-	PageSize int `json:"page_size" form:"page_size"`
+	PageSize int `json:"page_size" form:"page_size" binding:"omitempty,min=1,max=100"`
+	// 游标，传入上一页返回的next_cursor，第一页不传
+	Cursor string `json:"cursor" form:"cursor" binding:"max=1000"`
+	// 游标分页的每页大小
+	Limit int `json:"limit" form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// CursorMode 是否使用游标分页
+func (r *PageReq) CursorMode() bool {
+	return r.Cursor != "" || r.Limit > 0
+}
+
+// Size 每页大小，未指定时使用默认值，且不超过上限
+func (r *PageReq) Size() int {
+	size := r.PageSize
+	if r.CursorMode() {
+		size = r.Limit
+	}
+	if size <= 0 {
+		return DefaultPageSize
+	}
+	if size > MaxPageSize {
+		return MaxPageSize
+	}
+	return size
 }
 
 func (r *PageReq) Offset() int {
-	return (r.Page - 1) * r.PageSize
+	if r.Page <= 1 {
+		return 0
+	}
+	return (r.Page - 1) * r.Size()
+}
+
+// @Description 排序请求
+type SortReq struct {
+	// 排序字段，多个字段用逗号分隔，字段前加"-"表示倒序，如 -created_at,user_name
+	Sort string `json:"sort" form:"sort"`
 }
 
 // @Description 分页查询请求
 type PageUserReq struct {
 	PageReq
+	SortReq
 	// 用户名
 	UserName string `json:"user_name" form:"user_name"`
+	// 昵称
+	Nickname string `json:"nickname" form:"nickname"`
+	// 用户名及昵称的匹配方式：exact(默认)、prefix、fuzzy
+	Match string `json:"match" form:"match"`
+	// 用户状态，多个状态用逗号分隔
+	Status string `json:"status" form:"status"`
+	// 注册时间起始，毫秒时间戳
+	CreatedFrom int64 `json:"created_from" form:"created_from"`
+	// 注册时间截止，毫秒时间戳
+	CreatedTo int64 `json:"created_to" form:"created_to"`
 }
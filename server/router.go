@@ -4,7 +4,9 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"singo/api"
+	"singo/conf"
 	"singo/middleware"
+	"singo/model"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,25 +19,175 @@ func NewRouter() *gin.Engine {
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// 本地存储的上传文件
+	if cfg := conf.GetConfig().Storage; cfg.Driver != "s3" {
+		r.Static("/uploads", cfg.Local.Dir)
+	}
+
+	// 公钥集合，供下游服务验证Token
+	r.GET("/.well-known/jwks.json", api.JWKS)
+
+	// OAuth2授权服务
+	oauth := r.Group("/oauth")
+	{
+		oauth.POST("token", api.OAuthToken)
+		oauth.POST("introspect", api.OAuthIntrospect)
+		oauth.POST("revoke", api.OAuthRevoke)
+
+		// 授权确认，需要用户登录
+		consent := oauth.Group("")
+		consent.Use(middleware.AuthMiddleware(), middleware.DenyImpersonation())
+		consent.GET("authorize", api.OAuthAuthorizeInfo)
+		consent.POST("authorize", api.OAuthAuthorize)
+	}
+
 	// 路由
 	v1 := r.Group("/api/v1")
 	{
 		v1.GET("ping", api.Ping)
 
+		// 人机验证
+		v1.GET("captcha", api.Captcha)
+
 		user := v1.Group("user")
 
 		// 用户登录
-		user.POST("login", api.UserLogin)
+		user.POST("login", middleware.Captcha("login"), api.UserLogin)
+		user.POST("login/mfa", api.UserLoginMfa)
+
+		// 短信验证码登录
+		user.POST("sms/send", middleware.Captcha("sms"), api.SmsSend)
+		user.POST("sms/login", api.SmsLogin)
 
 		// 用户注册
-		user.POST("register", api.UserRegister)
+		user.POST("register", middleware.Captcha("register"), api.UserRegister)
 
-		user.Use(middleware.AuthMiddleware())
+		// 邮箱验证
+		user.GET("verify", api.UserVerify)
+		user.POST("verify/resend", api.UserVerifyResend)
+
+		// 找回密码
+		user.POST("password/forgot", api.PasswordForgot)
+		user.POST("password/reset", api.PasswordReset)
+
+		// 注销宽限期内恢复账号
+		user.POST("restore", middleware.Captcha("login"), api.UserRestore)
+
+		// 刷新Token
+		user.POST("token/refresh", api.UserTokenRefresh)
+
+		// 外部身份登录
+		user.GET("oidc/:provider/login", api.OidcLogin)
+		user.GET("oidc/:provider/callback", api.OidcCallback)
+
+		// 需要登录保护的，同时支持API Key访问
+		user.Use(middleware.AnyAuthMiddleware())
 
-		// 需要登录保护的
 		user.GET("info", api.UserMe)
 
-		user.GET("list", api.Get)
+		user.GET("list", middleware.RequirePermission(model.PermUserList), api.Get)
+
+		// 账号安全相关，仅允许登录Token访问
+		account := user.Group("")
+		account.Use(middleware.RequireSession())
+		{
+			// 用户注销
+			account.POST("logout", api.UserLogout)
+
+			account.GET("sessions", api.UserSessions)
+			account.GET("apikeys", api.ApiKeys)
+			account.GET("identities", api.Identities)
+			account.GET("login-history", api.UserLoginHistory)
+
+			// 个人资料
+			account.PATCH("info", api.UserUpdate)
+			account.POST("avatar", api.UserAvatar)
+
+			// 敏感操作，模拟登录时禁止访问
+			sensitive := account.Group("")
+			sensitive.Use(middleware.DenyImpersonation())
+
+			// 设置及修改密码
+			sensitive.POST("password", api.PasswordSet)
+			sensitive.PUT("password", api.PasswordChange)
+
+			// 两步验证
+			sensitive.POST("mfa/setup", api.MfaSetup)
+			sensitive.POST("mfa/enable", api.MfaEnable)
+			sensitive.POST("mfa/disable", api.MfaDisable)
+			sensitive.POST("mfa/recovery-codes", api.MfaRecoveryCodes)
+
+			// 登录设备管理
+			sensitive.DELETE("sessions/:id", api.UserSessionDelete)
+
+			// API Key管理
+			sensitive.POST("apikeys", api.ApiKeyCreate)
+			sensitive.DELETE("apikeys/:id", api.ApiKeyDelete)
+
+			// 外部身份绑定
+			sensitive.POST("oidc/:provider/link", api.OidcLink)
+			sensitive.DELETE("identities/:id", api.IdentityDelete)
+
+			// 注销账号及导出个人数据
+			sensitive.DELETE("me", api.UserDelete)
+			sensitive.GET("export", api.UserExport)
+		}
+
+		// 管理员接口
+		admin := v1.Group("admin")
+		admin.Use(middleware.AnyAuthMiddleware(), middleware.DenyImpersonation())
+		{
+			// 模拟用户登录，仅允许登录Token访问
+			admin.POST("user/impersonate", middleware.RequireSession(), middleware.RequirePermission(model.PermUserImpersonate), api.AdminImpersonateUser)
+
+			// 审计日志
+			admin.GET("audit-logs", middleware.RequirePermission(model.PermAuditView), api.AdminAuditLogs)
+
+			// 强制用户下线
+			admin.POST("user/revoke", middleware.RequirePermission(model.PermUserRevoke), api.AdminRevokeUser)
+
+			// 解除账号锁定
+			admin.POST("user/unlock", middleware.RequirePermission(model.PermUserUnlock), api.AdminUnlockUser)
+
+			// 封禁、解封及删除用户
+			moderate := admin.Group("user")
+			moderate.Use(middleware.RequirePermission(model.PermUserModerate))
+			moderate.POST("suspend", api.AdminSuspendUser)
+			moderate.POST("reactivate", api.AdminReactivateUser)
+			moderate.POST("delete", api.AdminDeleteUser)
+
+			// OAuth2客户端管理
+			oauthClient := admin.Group("oauth")
+			oauthClient.Use(middleware.RequirePermission(model.PermOAuthManage))
+			oauthClient.POST("clients", api.AdminOAuthClientCreate)
+			oauthClient.GET("clients", api.AdminOAuthClients)
+			oauthClient.DELETE("clients/:client_id", api.AdminOAuthClientDelete)
+
+			// 开放平台应用管理
+			app := admin.Group("")
+			app.Use(middleware.RequirePermission(model.PermAppManage))
+			app.POST("apps", api.AdminAppCreate)
+			app.GET("apps", api.AdminApps)
+			app.PUT("apps/:app_key", api.AdminAppStatus)
+			app.DELETE("apps/:app_key", api.AdminAppDelete)
+
+			// 角色管理
+			role := admin.Group("")
+			role.Use(middleware.RequirePermission(model.PermRoleManage))
+			role.GET("roles", api.AdminRoles)
+			role.POST("roles", api.AdminRoleCreate)
+			role.PUT("roles/:id", api.AdminRoleUpdate)
+			role.DELETE("roles/:id", api.AdminRoleDelete)
+			role.GET("permissions", api.AdminPermissions)
+			role.PUT("user/roles", api.AdminUserRoles)
+		}
+	}
+
+	// 开放接口，使用应用签名认证
+	open := r.Group("/api/open/v1")
+	open.Use(middleware.SignatureMiddleware())
+	{
+		open.GET("ping", api.OpenPing)
 	}
 	return r
 }
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"singo/cache"
+	"singo/conf"
+	"singo/data"
+	"singo/logger"
+	"singo/model"
+	"singo/req"
+	"singo/storage"
+	"time"
+)
+
+// purgeBatch 每次清除任务处理的用户数
+const purgeBatch = 100
+
+// @Description 注销账号请求
+type UserDeleteReq struct {
+	// 当前密码，未设置密码的账号可不填
+	Password string `form:"password" json:"password"`
+}
+
+// DeleteAccount 用户注销账号，立即软删除并撤销全部会话及OAuth2 Token，宽限期后清除个人信息
+func DeleteAccount(ctx context.Context, username string, service *UserDeleteReq) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	if user.PasswordDigest != "" && !user.CheckPassword(service.Password) {
+		return data.NewErrorResponse(20019, "密码错误")
+	}
+
+	if err = rep().WithCtx(ctx).Delete(user).Error; err != nil {
+		logger.Error("删除用户错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "注销账号失败")
+	}
+	revokeUserTokens(user.UserName)
+	grace := conf.GetConfig().Account.DeleteGrace
+	return data.NewSuccessResponse(fmt.Sprintf("账号已注销，个人信息将在%d天后彻底清除，期间可恢复账号", int(grace.Hours()/24)))
+}
+
+// pendingDeletionResponse 登录的账号处于注销宽限期内
+func pendingDeletionResponse() *data.Response {
+	return data.NewErrorResponse(20048, "账号已注销，可在宽限期内恢复账号")
+}
+
+// @Description 恢复账号请求，使用用户名及密码或手机号及短信验证码校验身份
+type UserRestoreReq struct {
+	// 用户名
+	UserName string `form:"user_name" json:"user_name" binding:"omitempty,min=5,max=30"`
+	// 密码
+	Password string `form:"password" json:"password" binding:"max=40"`
+	// 手机号
+	Phone string `form:"phone" json:"phone"`
+	// 短信验证码
+	Code string `form:"code" json:"code" binding:"omitempty,len=6,numeric"`
+	// 设备名称
+	Device string `form:"device" json:"device" binding:"max=50"`
+}
+
+// RestoreAccount 注销宽限期内撤销注销，校验身份后恢复账号并登录
+func RestoreAccount(service *UserRestoreReq, client *req.Client) *data.Response {
+	var user *model.User
+	var err error
+	switch {
+	case service.Phone != "":
+		if !phonePattern.MatchString(service.Phone) {
+			return data.NewErrorResponse(20042, "手机号格式错误")
+		}
+		if resp := checkSmsCode(service.Phone, service.Code); resp != nil {
+			return resp
+		}
+		if user, err = rep().GetPendingDeletionUser("phone = ?", service.Phone); err != nil {
+			return data.NewErrorResponse(20049, "没有可恢复的账号")
+		}
+	case service.UserName != "":
+		userSubject := cache.LoginUserSubject(service.UserName)
+		if resp := loginThrottled(userSubject, cache.LoginIPSubject(client.IP)); resp != nil {
+			deleted, e := rep().GetPendingDeletionUser("user_name = ?", service.UserName)
+			if e != nil {
+				deleted = nil
+			}
+			recordLogin(deleted, service.UserName, service.Device, client, model.LoginThrottled)
+			return resp
+		}
+		user, err = rep().GetPendingDeletionUser("user_name = ?", service.UserName)
+		if err != nil {
+			checkDummyPassword(service.Password)
+		}
+		if err != nil || !user.CheckPassword(service.Password) {
+			loginFailed(service.UserName, client.IP)
+			return data.NewErrorResponse(20003, "账号或密码错误")
+		}
+	default:
+		return data.ParamErr("请填写用户名及密码或手机号及验证码")
+	}
+
+	if err = rep().As(user.UserName).RestoreUser(user); err != nil {
+		logger.Error("恢复用户错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "恢复账号失败")
+	}
+	return completeLogin(user, service.Device, client)
+}
+
+// PurgeDeletedUsers 清除超过宽限期的已删除用户的个人信息及上传的头像
+func PurgeDeletedUsers() {
+	before := time.Now().Add(-conf.GetConfig().Account.DeleteGrace)
+	for {
+		users, err := rep().GetUsersToPurge(before, purgeBatch)
+		if err != nil {
+			logger.Error("查询待清除用户错误", err)
+			return
+		}
+		for _, user := range users {
+			username := user.UserName
+			// 先删除头像，失败时不清除用户，下次任务重试
+			if err = storage.GetStorage().DeletePrefix(avatarPrefix(user.ID)); err != nil {
+				logger.Error("删除头像错误", user.ID, err)
+				return
+			}
+			if err = rep().PurgeUser(user); err != nil {
+				logger.Error("清除用户信息错误", user.ID, err)
+				return
+			}
+			revokeUserTokens(username)
+		}
+		if len(users) < purgeBatch {
+			return
+		}
+	}
+}
+
+// StartPurgeJob 按配置的间隔定时执行清除任务
+func StartPurgeJob() {
+	interval := conf.GetConfig().Account.PurgeInterval
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		PurgeDeletedUsers()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			PurgeDeletedUsers()
+		}
+	}()
+}
+
+// ExportUserData 导出用户的全部数据，返回包含多个JSON文件及上传的头像的zip压缩包
+// 两步验证只导出开启状态及恢复码使用情况，密钥及恢复码本身不导出
+func ExportUserData(username string) ([]byte, *data.Response) {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return nil, data.NewErrorResponse(20002, "查询用户失败")
+	}
+
+	// 任意一项查询失败都不返回不完整的数据
+	failed := func(msg string, err error) ([]byte, *data.Response) {
+		logger.Error(msg, err)
+		return nil, data.NewErrorResponse(20046, "导出数据失败")
+	}
+	sessions, err := redis().ListSessions(user.UserName)
+	if err != nil {
+		return failed("查询会话错误", err)
+	}
+	apiKeys, err := rep().GetApiKeys(user.ID)
+	if err != nil {
+		return failed("查询API Key错误", err)
+	}
+	identities, err := rep().GetIdentities(user.ID)
+	if err != nil {
+		return failed("查询外部身份错误", err)
+	}
+	codes, err := rep().GetRecoveryCodes(user.ID)
+	if err != nil {
+		return failed("查询恢复码错误", err)
+	}
+	var consents []*model.OAuthConsent
+	if err = rep().Where("user_id = ?", user.ID).Find(&consents).Error; err != nil {
+		return failed("查询授权记录错误", err)
+	}
+	var histories []*model.LoginHistory
+	if err = rep().Where("user_id = ?", user.ID).Order("id desc").Find(&histories).Error; err != nil {
+		return failed("查询登录历史错误", err)
+	}
+	var audits []*model.AuditLog
+	if err = rep().Where("actor = ? OR target = ?", user.UserName, user.UserName).Order("id desc").Find(&audits).Error; err != nil {
+		return failed("查询审计日志错误", err)
+	}
+
+	files := map[string]interface{}{
+		"profile.json":       data.BuildUser(user),
+		"mfa.json":           data.BuildMfaInfo(user, codes),
+		"sessions.json":      data.BuildSessions(sessions, ""),
+		"api_keys.json":      data.BuildApiKeys(apiKeys),
+		"identities.json":    data.BuildIdentities(identities),
+		"oauth_grants.json":  data.BuildOAuthGrants(consents),
+		"login_history.json": data.BuildLoginHistories(histories),
+		"audit_logs.json":    data.BuildAuditLogs(audits),
+	}
+	// 上传的头像原样导出，外部身份提供的头像只保留profile.json中的地址
+	if key := avatarKey(user.ID, user.Avatar); key != "" {
+		avatar, err := storage.GetStorage().Get(key)
+		if err != nil {
+			return failed("读取头像错误", err)
+		}
+		files["avatar"+path.Ext(key)] = avatar
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err == nil {
+			if raw, ok := content.([]byte); ok {
+				_, err = w.Write(raw)
+			} else {
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(content)
+			}
+		}
+		if err != nil {
+			return failed("生成导出文件错误", err)
+		}
+	}
+	if err = zw.Close(); err != nil {
+		logger.Error("生成导出文件错误", err)
+		return nil, data.NewErrorResponse(20046, "导出数据失败")
+	}
+	return buf.Bytes(), nil
+}
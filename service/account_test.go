@@ -0,0 +1,183 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"singo/data"
+	"singo/model"
+	"singo/testutil"
+	"singo/util"
+	"strings"
+	"testing"
+	"time"
+)
+
+// expireGrace 将已删除用户的删除时间提前到宽限期之前
+func expireGrace(t *testing.T, username string) {
+	t.Helper()
+	err := rep().Unscoped().Model(&model.User{}).Where("user_name = ?", username).
+		Update("deleted_at", time.Now().Add(-31*24*time.Hour)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPurgeDeletesAvatar(t *testing.T) {
+	testutil.Setup(t)
+	dir := setupStorage(t)
+	user := createTestUser(t, "alice01", "alice@example.com", "")
+	if resp := UploadAvatar(userCtx("alice01"), "alice01", multipartFile(t, "a.png", pngImage(t, 100, 100))); !resp.Success {
+		t.Fatalf("UploadAvatar() = %+v", resp)
+	}
+
+	if resp := DeleteAccount(userCtx("alice01"), "alice01", &UserDeleteReq{}); !resp.Success {
+		t.Fatalf("DeleteAccount() = %+v", resp)
+	}
+	// 宽限期内不清除
+	PurgeDeletedUsers()
+	if countFiles(t, dir) == 0 {
+		t.Fatal("宽限期内头像被删除")
+	}
+
+	expireGrace(t, "alice01")
+	PurgeDeletedUsers()
+	if count := countFiles(t, dir); count != 0 {
+		t.Errorf("清除后剩余文件数 = %d", count)
+	}
+	var purged model.User
+	if err := rep().Unscoped().First(&purged, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if purged.PurgedAt == nil || purged.Avatar != "" || purged.Email != "" {
+		t.Errorf("清除后的用户 = %+v", purged)
+	}
+}
+
+func TestRestoreAccountBySms(t *testing.T) {
+	testutil.Setup(t)
+	resp := smsLogin(t, "13800138000")
+	if !resp.Success {
+		t.Fatalf("短信登录 = %+v", resp)
+	}
+	user := resp.Data.(*data.UserReq)
+	if resp = DeleteAccount(userCtx(user.UserName), user.UserName, &UserDeleteReq{}); !resp.Success {
+		t.Fatalf("DeleteAccount() = %+v", resp)
+	}
+
+	// 宽限期内手机号仍属于原账号，登录提示注销中而不是创建新账号
+	if resp = smsLogin(t, "13800138000"); resp.ErrCode != 20048 {
+		t.Fatalf("注销后短信登录 = %+v, want 20048", resp)
+	}
+	if count := countUsers(t); count != 0 {
+		t.Errorf("用户数 = %d, want 0", count)
+	}
+
+	if err := redis().SetSmsCode("13800138000", util.HashToken("123456"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	resp = RestoreAccount(&UserRestoreReq{Phone: "13800138000", Code: "123456"}, testClient)
+	if !resp.Success || resp.Data.(*data.UserReq).ID != user.ID {
+		t.Fatalf("恢复账号 = %+v", resp)
+	}
+	if resp = smsLogin(t, "13800138000"); !resp.Success || resp.Data.(*data.UserReq).ID != user.ID {
+		t.Errorf("恢复后短信登录 = %+v", resp)
+	}
+}
+
+func TestRestoreAccountByPassword(t *testing.T) {
+	testutil.Setup(t)
+	createTestUser(t, "alice01", "alice@example.com", "Gz8#kq2Lmv")
+	if resp := DeleteAccount(userCtx("alice01"), "alice01", &UserDeleteReq{Password: "Gz8#kq2Lmv"}); !resp.Success {
+		t.Fatalf("DeleteAccount() = %+v", resp)
+	}
+
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, testClient); resp.ErrCode != 20048 {
+		t.Errorf("注销后登录 = %+v, want 20048", resp)
+	}
+	// 密码错误时不泄露账号状态
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmw"}, testClient); resp.ErrCode != 20003 {
+		t.Errorf("注销后密码错误 = %+v, want 20003", resp)
+	}
+	if resp := RestoreAccount(&UserRestoreReq{UserName: "alice01", Password: "Gz8#kq2Lmw"}, testClient); resp.ErrCode != 20003 {
+		t.Errorf("密码错误时恢复 = %+v, want 20003", resp)
+	}
+
+	if resp := RestoreAccount(&UserRestoreReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, testClient); !resp.Success {
+		t.Fatalf("恢复账号 = %+v", resp)
+	}
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, testClient); !resp.Success {
+		t.Errorf("恢复后登录 = %+v", resp)
+	}
+
+	// 清除后不可恢复
+	if resp := DeleteAccount(userCtx("alice01"), "alice01", &UserDeleteReq{Password: "Gz8#kq2Lmv"}); !resp.Success {
+		t.Fatalf("DeleteAccount() = %+v", resp)
+	}
+	expireGrace(t, "alice01")
+	PurgeDeletedUsers()
+	if resp := RestoreAccount(&UserRestoreReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, testClient); resp.Success {
+		t.Errorf("清除后恢复 = %+v", resp)
+	}
+}
+
+// readZip 解压导出的压缩包，返回文件名到内容的映射
+func readZip(t *testing.T, archive []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		_ = r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = content
+	}
+	return files
+}
+
+func TestExportUserData(t *testing.T) {
+	server := testutil.Setup(t)
+	setupStorage(t)
+	createTestUser(t, "alice01", "alice@example.com", "")
+	if resp := UploadAvatar(userCtx("alice01"), "alice01", multipartFile(t, "a.png", pngImage(t, 100, 100))); !resp.Success {
+		t.Fatalf("UploadAvatar() = %+v", resp)
+	}
+	user, _ := rep().GetUser("alice01")
+	if err := rep().ReplaceRecoveryCodes(user.ID, []string{"hash-1", "hash-2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, resp := ExportUserData("alice01")
+	if resp != nil {
+		t.Fatalf("ExportUserData() = %+v", resp)
+	}
+	files := readZip(t, archive)
+	for _, name := range []string{"profile.json", "mfa.json", "sessions.json", "login_history.json", "avatar.png"} {
+		if len(files[name]) == 0 {
+			t.Errorf("缺少导出文件 %s", name)
+		}
+	}
+	var mfa data.MfaInfoReq
+	if err := json.Unmarshal(files["mfa.json"], &mfa); err != nil || len(mfa.RecoveryCodes) != 2 {
+		t.Errorf("mfa.json = %s", files["mfa.json"])
+	}
+	if strings.Contains(string(files["mfa.json"]), "hash-1") {
+		t.Error("导出了恢复码摘要")
+	}
+
+	// 查询失败时不返回不完整的数据
+	server.Close()
+	if archive, resp = ExportUserData("alice01"); resp == nil || resp.ErrCode != 20046 || archive != nil {
+		t.Errorf("Redis不可用时导出 = %+v", resp)
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"singo/data"
+	"singo/logger"
+	"singo/middleware"
+	"singo/model"
+	"singo/util"
+	"strings"
+	"time"
+)
+
+// maxApiKeys 每个用户最多创建的API Key数量
+const maxApiKeys = 20
+
+// @Description 创建API Key请求
+type ApiKeyCreateReq struct {
+	// 名称
+	Name string `form:"name" json:"name" binding:"required,min=1,max=50"`
+	// 授权范围，权限编码列表，为空表示继承用户全部权限
+	Scopes []string `form:"scopes" json:"scopes"`
+	// 过期时间(毫秒时间戳)，为空表示永不过期
+	ExpireAt int64 `form:"expire_at" json:"expire_at"`
+}
+
+// newApiKey 生成API Key，返回前缀及完整Key
+func newApiKey() (prefix, key string, err error) {
+	b := make([]byte, 4)
+	if _, err = rand.Read(b); err != nil {
+		return
+	}
+	prefix = hex.EncodeToString(b)
+	secret, err := util.RandomToken(24)
+	if err != nil {
+		return
+	}
+	key = fmt.Sprintf("%s_%s_%s", middleware.ApiKeyPrefix, prefix, secret)
+	return
+}
+
+// CreateApiKey 创建API Key，完整Key仅在创建时返回一次
+func CreateApiKey(ctx context.Context, username string, service *ApiKeyCreateReq) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+
+	count := int64(0)
+	rep().Model(&model.ApiKey{}).Where("user_id = ?", user.ID).Count(&count)
+	if count >= maxApiKeys {
+		return data.NewErrorResponse(20027, fmt.Sprintf("最多创建%d个API Key", maxApiKeys))
+	}
+
+	if len(service.Scopes) > 0 {
+		perms, err := rep().GetPermissionsByCodes(service.Scopes)
+		if err != nil || len(perms) != len(service.Scopes) {
+			return data.ParamErr("授权范围不存在")
+		}
+	}
+
+	var expiresAt *time.Time
+	if service.ExpireAt > 0 {
+		t := time.UnixMilli(service.ExpireAt)
+		if t.Before(time.Now()) {
+			return data.ParamErr("过期时间必须晚于当前时间")
+		}
+		expiresAt = &t
+	}
+
+	prefix, key, err := newApiKey()
+	if err != nil {
+		logger.Error("生成API Key错误", err)
+		return data.NewErrorResponse(20028, "创建API Key失败")
+	}
+
+	apiKey := model.ApiKey{
+		UserID:    user.ID,
+		Name:      service.Name,
+		Prefix:    prefix,
+		KeyHash:   util.HashToken(key),
+		Scopes:    strings.Join(service.Scopes, ","),
+		ExpiresAt: expiresAt,
+	}
+	if err = rep().WithCtx(ctx).Create(&apiKey).Error; err != nil {
+		logger.Error("创建API Key错误", err)
+		return data.NewErrorResponse(20028, "创建API Key失败")
+	}
+
+	resp := data.BuildApiKey(&apiKey)
+	resp.Key = key
+	return data.NewDataResponse(resp)
+}
+
+// ListApiKeys 列出用户全部API Key
+func ListApiKeys(username string) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+
+	keys, err := rep().GetApiKeys(user.ID)
+	if err != nil {
+		logger.Error("查询API Key错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "查询API Key失败")
+	}
+	return data.NewDataResponse(data.BuildApiKeys(keys))
+}
+
+// DeleteApiKey 删除API Key，立即失效
+func DeleteApiKey(ctx context.Context, username string, id uint) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+
+	res := rep().WithCtx(ctx).Where("id = ? AND user_id = ?", id, user.ID).Delete(&model.ApiKey{})
+	if res.Error != nil {
+		logger.Error("删除API Key错误", res.Error)
+		return data.NewErrorResponse(data.CodeDBError, "删除API Key失败")
+	}
+	if res.RowsAffected == 0 {
+		return data.NewErrorResponse(20029, "API Key不存在")
+	}
+	return data.NewSuccessResponse("删除成功")
+}
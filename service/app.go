@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"singo/conf"
+	"singo/data"
+	"singo/logger"
+	"singo/model"
+	"singo/util"
+)
+
+// @Description 开放平台应用创建请求
+type AppClientCreateReq struct {
+	// 名称
+	Name string `form:"name" json:"name" binding:"required,min=2,max=100"`
+}
+
+// CreateAppClient 创建开放平台应用，应用密钥仅返回一次
+func CreateAppClient(ctx context.Context, service *AppClientCreateReq) *data.Response {
+	appKey := randomHex(16)
+	secret, err := util.RandomToken(32)
+	if err != nil {
+		logger.Error("生成应用密钥错误", err)
+		return data.NewErrorResponse(40201, "创建应用失败")
+	}
+	// 签名校验需要原始密钥，因此加密保存而不是哈希
+	encrypted, err := util.Encrypt(secret, conf.GetConfig().Server.EncryptKey)
+	if err != nil {
+		logger.Error("加密应用密钥错误", err)
+		return data.NewErrorResponse(40201, "创建应用失败")
+	}
+
+	app := model.AppClient{
+		AppKey:    appKey,
+		AppSecret: encrypted,
+		Name:      service.Name,
+		Status:    model.AppActive,
+	}
+	if err = rep().WithCtx(ctx).Create(&app).Error; err != nil {
+		logger.Error("创建应用错误", err)
+		return data.NewErrorResponse(40201, "创建应用失败")
+	}
+
+	resp := data.BuildAppClient(&app)
+	resp.AppSecret = secret
+	return data.NewDataResponse(resp)
+}
+
+// ListAppClients 获取全部开放平台应用
+func ListAppClients() *data.Response {
+	apps, err := rep().GetAppClients()
+	if err != nil {
+		logger.Error("查询应用错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "查询应用失败")
+	}
+	return data.NewDataResponse(data.BuildAppClients(apps))
+}
+
+// @Description 开放平台应用状态修改请求
+type AppClientStatusReq struct {
+	// 状态
+	Status string `form:"status" json:"status" binding:"required,oneof=active disabled"`
+}
+
+// UpdateAppClientStatus 启用或停用开放平台应用
+func UpdateAppClientStatus(ctx context.Context, appKey string, service *AppClientStatusReq) *data.Response {
+	res := rep().WithCtx(ctx).Model(&model.AppClient{}).Where("app_key = ?", appKey).Update("status", service.Status)
+	if res.Error != nil {
+		logger.Error("修改应用状态错误", res.Error)
+		return data.NewErrorResponse(data.CodeDBError, "修改应用状态失败")
+	}
+	if res.RowsAffected == 0 {
+		return data.NewErrorResponse(40202, "应用不存在")
+	}
+	return data.NewSuccessResponse("修改成功")
+}
+
+// DeleteAppClient 删除开放平台应用
+func DeleteAppClient(ctx context.Context, appKey string) *data.Response {
+	res := rep().WithCtx(ctx).Where("app_key = ?", appKey).Delete(&model.AppClient{})
+	if res.Error != nil {
+		logger.Error("删除应用错误", res.Error)
+		return data.NewErrorResponse(data.CodeDBError, "删除应用失败")
+	}
+	if res.RowsAffected == 0 {
+		return data.NewErrorResponse(40202, "应用不存在")
+	}
+	return data.NewSuccessResponse("删除成功")
+}
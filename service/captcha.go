@@ -0,0 +1,52 @@
+package service
+
+import (
+	"encoding/base64"
+	"singo/captcha"
+	"singo/conf"
+	"singo/data"
+	"singo/logger"
+	"time"
+)
+
+// @Description 获取验证码请求
+type CaptchaReq struct {
+	// 验证码类型，为空时使用默认配置
+	Type string `form:"type" json:"type" binding:"omitempty,oneof=image slider"`
+}
+
+// NewCaptcha 生成验证码，答案保存在Redis中
+func NewCaptcha(service *CaptchaReq) *data.Response {
+	cfg := conf.GetConfig().Captcha
+	kind := service.Type
+	if kind == "" {
+		kind = cfg.Type
+	}
+
+	challenge, err := captcha.New(kind, cfg.Length)
+	if err != nil {
+		logger.Error("生成验证码错误", err)
+		return data.NewErrorResponse(20038, "生成验证码失败")
+	}
+	id := randomHex(16)
+	if err = redis().SetCaptcha(id, challenge.Stored(), cfg.Expire); err != nil {
+		logger.Error("存储验证码错误", err)
+		return data.NewErrorResponse(20038, "生成验证码失败")
+	}
+
+	resp := &data.CaptchaReq{
+		ID:       id,
+		Type:     challenge.Type,
+		Image:    pngDataURI(challenge.Image),
+		PieceY:   challenge.PieceY,
+		ExpireAt: time.Now().Add(cfg.Expire).UnixMilli(),
+	}
+	if challenge.Piece != nil {
+		resp.Piece = pngDataURI(challenge.Piece)
+	}
+	return data.NewDataResponse(resp)
+}
+
+func pngDataURI(b []byte) string {
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(b)
+}
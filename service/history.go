@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"singo/conf"
+	"singo/data"
+	"singo/geoip"
+	"singo/logger"
+	"singo/mailer"
+	"singo/model"
+	"singo/req"
+	"singo/util"
+	"strings"
+)
+
+// LoginAlert 新设备或新地区登录提醒
+type LoginAlert struct {
+	User    *model.User
+	History *model.LoginHistory
+	// 是否为从未使用过的设备
+	NewDevice bool
+	// 是否为从未登录过的国家或地区
+	NewCountry bool
+}
+
+// OnLoginAlert 新设备或新地区登录时的通知钩子，默认发送提醒邮件，可在启动时替换
+var OnLoginAlert = mailLoginAlert
+
+// recordLogin 记录登录历史，reason为空表示登录成功，成功时检测是否为新设备或新地区
+func recordLogin(user *model.User, username, device string, client *req.Client, reason string) {
+	ua := util.ParseUserAgent(client.UserAgent)
+	history := &model.LoginHistory{
+		UserName:   username,
+		Success:    reason == "",
+		Reason:     reason,
+		IP:         client.IP,
+		UserAgent:  truncate(client.UserAgent, 500),
+		Device:     device,
+		Browser:    ua.Browser,
+		OS:         ua.OS,
+		DeviceType: ua.DeviceType,
+	}
+	if user != nil {
+		history.UserID = user.ID
+	}
+	if location := geoip.Lookup(client.IP); location != nil {
+		history.Country = location.Country
+		history.Region = location.Region
+		history.City = location.City
+	}
+
+	var alert *LoginAlert
+	if history.Success {
+		alert = detectLoginAlert(user, history)
+	}
+	if err := rep().AddLoginHistory(history); err != nil {
+		logger.Error("写入登录历史错误", err)
+	}
+	if alert != nil && conf.GetConfig().Login.Notify {
+		go OnLoginAlert(alert)
+	}
+}
+
+// detectLoginAlert 与历史成功登录比较，首次登录不提醒
+func detectLoginAlert(user *model.User, history *model.LoginHistory) *LoginAlert {
+	total, err := rep().CountLoginSuccess(user.ID, nil)
+	if err != nil || total == 0 {
+		return nil
+	}
+	alert := &LoginAlert{User: user, History: history}
+
+	count, err := rep().CountLoginSuccess(user.ID, map[string]interface{}{
+		"browser":     history.Browser,
+		"os":          history.OS,
+		"device_type": history.DeviceType,
+	})
+	alert.NewDevice = err == nil && count == 0
+
+	if history.Country != "" {
+		count, err = rep().CountLoginSuccess(user.ID, map[string]interface{}{"country": history.Country})
+		alert.NewCountry = err == nil && count == 0
+	}
+
+	if !alert.NewDevice && !alert.NewCountry {
+		return nil
+	}
+	return alert
+}
+
+// mailLoginAlert 向用户邮箱发送登录提醒
+func mailLoginAlert(alert *LoginAlert) {
+	if alert.User.Email == "" {
+		return
+	}
+	h := alert.History
+	location := strings.Trim(strings.Join([]string{h.Country, h.Region, h.City}, " "), " ")
+	if location == "" {
+		location = "未知"
+	}
+	body := fmt.Sprintf("%s，您好：\n\n您的账号于%s在新的设备或地区登录：\n设备：%s / %s\nIP：%s\n地区：%s\n\n如非本人操作，请立即修改密码并在登录设备管理中下线该设备。",
+		alert.User.Nickname, h.CreatedAt.Format("2006-01-02 15:04:05"), h.Browser, h.OS, h.IP, location)
+	if err := mailer.GetMailer().Send(alert.User.Email, "账号登录提醒", body); err != nil {
+		logger.Error("发送登录提醒错误", err)
+	}
+}
+
+// ListLoginHistory 分页获取当前用户的登录历史
+func ListLoginHistory(username string, param *req.PageReq) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	total, next, array, err := rep().GetLoginHistory(user.ID, param)
+	if errors.Is(err, model.ErrInvalidQuery) {
+		return data.ParamErr(err.Error())
+	}
+	if err != nil {
+		logger.Error("查询登录历史错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "查询登录历史失败")
+	}
+	return pageResponse(param, total, next, data.BuildLoginHistories(array))
+}
+
+// truncate 按字符截断，避免截断多字节字符
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
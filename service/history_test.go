@@ -0,0 +1,51 @@
+package service
+
+import (
+	"singo/cache"
+	"singo/model"
+	"singo/req"
+	"singo/testutil"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestLoginThrottledRecorded(t *testing.T) {
+	testutil.Setup(t)
+	user := createTestUser(t, "alice01", "alice@example.com", "Gz8#kq2Lmv")
+
+	if err := redis().SetLoginWait(cache.LoginUserSubject("alice01"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, testClient); resp.ErrCode != 20010 {
+		t.Fatalf("登录限制中登录 = %+v", resp)
+	}
+
+	// 被限制的登录也记入登录历史
+	var history model.LoginHistory
+	if err := rep().Where("user_name = ?", "alice01").Last(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if history.Success || history.Reason != model.LoginThrottled || history.UserID != user.ID {
+		t.Errorf("登录历史 = %+v", history)
+	}
+}
+
+func TestLoginHistoryTruncateUserAgent(t *testing.T) {
+	testutil.Setup(t)
+	createTestUser(t, "alice01", "alice@example.com", "Gz8#kq2Lmv")
+
+	// 多字节字符的User-Agent按字符截断，不产生非法的UTF-8
+	client := &req.Client{IP: testClient.IP, UserAgent: strings.Repeat("浏览器", 200)}
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, client); !resp.Success {
+		t.Fatalf("登录 = %+v", resp)
+	}
+	var history model.LoginHistory
+	if err := rep().Where("user_name = ?", "alice01").Last(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.ValidString(history.UserAgent) || utf8.RuneCountInString(history.UserAgent) != 500 {
+		t.Errorf("User-Agent长度 = %d, 是否合法UTF-8 = %v", utf8.RuneCountInString(history.UserAgent), utf8.ValidString(history.UserAgent))
+	}
+}
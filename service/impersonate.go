@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"singo/cache"
+	"singo/conf"
+	"singo/data"
+	"singo/logger"
+	"singo/middleware"
+	"singo/model"
+	"singo/req"
+	"singo/util"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// @Description 模拟用户登录请求
+type UserImpersonateReq struct {
+	// 用户名
+	UserName string `form:"user_name" json:"user_name" binding:"required,min=5,max=30"`
+	// 原因，写入审计日志
+	Reason string `form:"reason" json:"reason" binding:"required,max=500"`
+}
+
+// Impersonate 管理员以指定用户身份登录，颁发不可刷新的短期Token并写入审计日志
+// 模拟登录不计入用户的会话列表，且禁止修改密码、两步验证等敏感操作
+func Impersonate(actor string, service *UserImpersonateReq, client *req.Client) *data.Response {
+	user, err := rep().GetUser(service.UserName)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	if user.UserName == actor {
+		return data.NewErrorResponse(20043, "不能模拟自己登录")
+	}
+	for _, role := range user.RoleNames() {
+		if role == model.AdminRole {
+			return data.NewErrorResponse(20043, "不能模拟管理员登录")
+		}
+	}
+	if resp := checkStatus(user); resp != nil {
+		return resp
+	}
+
+	sid, err := util.RandomToken(16)
+	if err != nil {
+		logger.Error("生成会话编号错误", err)
+		return data.NewErrorResponse(10000, "颁发Token错误")
+	}
+	resp := data.BuildUser(user)
+	expire := conf.GetConfig().Server.ImpersonateExpire
+	expireAt := time.Now().Add(expire)
+	token, err := middleware.SignToken(&middleware.Claims{
+		Username:  user.UserName,
+		SessionID: sid,
+		Roles:     resp.Roles,
+		Actor:     actor,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: expireAt.Unix(),
+		},
+	})
+	if err != nil {
+		logger.Error("颁发Token错误", err)
+		return data.NewErrorResponse(10000, "颁发Token错误")
+	}
+
+	// 审计日志写入失败时不颁发Token
+	err = rep().AddAuditLog(&model.AuditLog{
+		Actor:  actor,
+		Action: model.AuditImpersonate,
+		Target: user.UserName,
+		Detail: service.Reason,
+		IP:     client.IP,
+	})
+	if err != nil {
+		logger.Error("写入审计日志错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "写入审计日志失败")
+	}
+	imp := &cache.Impersonation{UserName: user.UserName, Actor: actor, Token: token}
+	if err = redis().SetImpersonation(sid, imp, expire); err != nil {
+		logger.Error("存储模拟登录错误", err)
+		return data.NewErrorResponse(10000, "颁发Token错误")
+	}
+
+	resp.Impersonator = actor
+	resp.Token = token
+	resp.TokenExpire = expire.Milliseconds()
+	return data.NewDataResponse(resp)
+}
+
+// ListAuditLogs 分页获取审计日志
+func ListAuditLogs(param *req.PageReq) *data.Response {
+	total, next, array, err := rep().GetAuditLogs(param)
+	if errors.Is(err, model.ErrInvalidQuery) {
+		return data.ParamErr(err.Error())
+	}
+	if err != nil {
+		logger.Error("查询审计日志错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "查询审计日志失败")
+	}
+	return pageResponse(param, total, next, data.BuildAuditLogs(array))
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"singo/cache"
+	"singo/conf"
+	"singo/data"
+	"singo/logger"
+	"singo/model"
+	"sync"
+	"time"
+)
+
+var dummyDigest string
+var dummyOnce sync.Once
+
+// checkDummyPassword 用户不存在时同样执行一次密码校验，避免通过响应时间判断用户是否存在
+func checkDummyPassword(password string) {
+	dummyOnce.Do(func() {
+		var user model.User
+		_ = user.SetPassword("dummy-password")
+		dummyDigest = user.PasswordDigest
+	})
+	user := model.User{PasswordDigest: dummyDigest}
+	user.CheckPassword(password)
+}
+
+// rehashPassword 登录成功后将旧算法或旧参数生成的密码摘要升级为当前配置
+func rehashPassword(user *model.User, password string) {
+	if !user.PasswordNeedsRehash() {
+		return
+	}
+	if err := user.SetPassword(password); err != nil {
+		logger.Error("升级密码摘要错误", err)
+		return
+	}
+	if err := rep().As(user.UserName).Model(user).Update("password_digest", user.PasswordDigest).Error; err != nil {
+		logger.Error("保存密码摘要错误", err)
+	}
+}
+
+// loginThrottled 检查账号及IP是否处于登录限制中
+func loginThrottled(subjects ...string) *data.Response {
+	for _, subject := range subjects {
+		wait, err := redis().GetLoginWait(subject)
+		if err != nil {
+			logger.Error("查询登录限制错误", err)
+			continue
+		}
+		if wait > 0 {
+			return data.NewErrorResponse(20010, fmt.Sprintf("登录失败次数过多，请%d秒后再试", int(wait.Seconds())+1))
+		}
+	}
+	return nil
+}
+
+// loginFailed 记录登录失败，按失败次数逐步延迟，超过阈值后锁定
+func loginFailed(username, ip string) {
+	cfg := conf.GetConfig().Login
+	subjects := map[string]int64{
+		cache.LoginUserSubject(username): cfg.MaxAttempts,
+		cache.LoginIPSubject(ip):         cfg.IPMaxAttempts,
+	}
+	for subject, max := range subjects {
+		count, err := redis().IncrLoginFail(subject, cfg.Window)
+		if err != nil {
+			logger.Error("记录登录失败错误", err)
+			continue
+		}
+
+		var wait time.Duration
+		switch {
+		case count >= max:
+			wait = cfg.LockDuration
+			logger.Warn("登录失败次数过多，已锁定", subject)
+		case count > cfg.FreeAttempts:
+			wait = cfg.BaseDelay << (count - cfg.FreeAttempts - 1)
+			if wait > cfg.MaxDelay || wait <= 0 {
+				wait = cfg.MaxDelay
+			}
+		}
+		if wait > 0 {
+			if err = redis().SetLoginWait(subject, wait); err != nil {
+				logger.Error("设置登录限制错误", err)
+			}
+		}
+	}
+}
+
+// checkStatus 检查用户状态是否允许登录，限时封禁到期的用户自动解封
+func checkStatus(user *model.User) *data.Response {
+	if user.SuspendExpired() {
+		if err := reactivate(context.Background(), user); err != nil {
+			logger.Error("自动解除封禁错误", err)
+		} else {
+			user.Status = model.Active
+		}
+	}
+
+	switch user.Status {
+	case model.Active:
+		return nil
+	case model.Inactive:
+		return data.NewErrorResponse(20012, "账号未激活，请先完成邮箱验证")
+	default:
+		return suspendedResponse(user)
+	}
+}
+
+// @Description 账号解锁请求
+type UserUnlockReq struct {
+	// 用户名
+	UserName string `form:"user_name" json:"user_name" binding:"required,min=5,max=30"`
+}
+
+// UnlockUser 管理员解除账号的登录锁定
+func UnlockUser(service *UserUnlockReq) *data.Response {
+	if err := redis().ClearLoginFail(cache.LoginUserSubject(service.UserName)); err != nil {
+		logger.Error("解除登录锁定错误", err)
+		return data.NewErrorResponse(20011, "解锁失败")
+	}
+	return data.NewSuccessResponse("解锁成功")
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"fmt"
+	"singo/cache"
+	"singo/conf"
+	"singo/hasher"
+	"singo/req"
+	"singo/testutil"
+	"testing"
+)
+
+func TestLoginLocksAccount(t *testing.T) {
+	server := testutil.Setup(t)
+	previous := hasher.GetHasher()
+	t.Cleanup(func() { hasher.SetHasher(previous) })
+	hasher.SetHasher(&hasher.BcryptHasher{Cost: 4})
+	createTestUser(t, "alice01", "alice@example.com", "Gz8#kq2Lmv")
+	cfg := conf.GetConfig().Login
+
+	for i := int64(1); i <= cfg.MaxAttempts; i++ {
+		if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmw"}, testClient); resp.ErrCode != 20003 {
+			t.Fatalf("第%d次错误密码 = %+v", i, resp)
+		}
+		// 超过免延迟次数后逐步延迟，等待延迟结束再继续尝试
+		if i > cfg.FreeAttempts && i < cfg.MaxAttempts {
+			if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmw"}, testClient); resp.ErrCode != 20010 {
+				t.Fatalf("第%d次失败后未延迟 = %+v", i, resp)
+			}
+			server.FastForward(cfg.MaxDelay)
+		}
+	}
+
+	// 达到最大失败次数后锁定，延迟结束后正确的密码也无法登录
+	server.FastForward(cfg.MaxDelay)
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, testClient); resp.ErrCode != 20010 {
+		t.Fatalf("锁定中登录 = %+v", resp)
+	}
+	// 其他IP同样无法登录该账号
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, &req.Client{IP: "10.0.0.2"}); resp.ErrCode != 20010 {
+		t.Fatalf("其他IP登录锁定账号 = %+v", resp)
+	}
+
+	server.FastForward(cfg.LockDuration)
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, testClient); !resp.Success {
+		t.Fatalf("锁定结束后登录 = %+v", resp)
+	}
+	// 登录成功后清除账号的失败次数
+	if count, _ := redis().GetLoginFail(cache.LoginUserSubject("alice01")); count != 0 {
+		t.Errorf("登录成功后失败次数 = %d", count)
+	}
+}
+
+func TestLoginLocksIP(t *testing.T) {
+	server := testutil.Setup(t)
+	createTestUser(t, "alice01", "alice@example.com", "Gz8#kq2Lmv")
+	cfg := conf.GetConfig().Login
+
+	// 同一IP尝试不同账号，失败次数按IP累计
+	for i := int64(0); i < cfg.IPMaxAttempts; i++ {
+		loginFailed(fmt.Sprintf("user%03d", i), testClient.IP)
+	}
+	server.FastForward(cfg.MaxDelay)
+	if resp := loginThrottled(cache.LoginIPSubject(testClient.IP)); resp == nil || resp.ErrCode != 20010 {
+		t.Fatalf("IP锁定 = %+v", resp)
+	}
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, testClient); resp.ErrCode != 20010 {
+		t.Errorf("锁定IP登录 = %+v", resp)
+	}
+	// 其他IP不受影响
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, &req.Client{IP: "10.0.0.2"}); !resp.Success {
+		t.Errorf("其他IP登录 = %+v", resp)
+	}
+}
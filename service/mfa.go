@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"singo/cache"
+	"singo/conf"
+	"singo/data"
+	"singo/logger"
+	"singo/model"
+	"singo/req"
+	"singo/util"
+	"strings"
+	"time"
+)
+
+// recoveryCodeCount 每次生成的恢复码数量
+const recoveryCodeCount = 10
+
+// mfaSecret 解密用户的两步验证密钥
+func mfaSecret(user *model.User) (string, error) {
+	return util.Decrypt(user.MfaSecret, conf.GetConfig().Server.EncryptKey)
+}
+
+// normalizeRecoveryCode 恢复码忽略大小写及分隔符
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// newRecoveryCodes 生成并保存恢复码，返回明文
+func newRecoveryCodes(ctx context.Context, user *model.User) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		secret, err := util.GenerateTOTPSecret()
+		if err != nil {
+			return nil, err
+		}
+		code := secret[:5] + "-" + secret[5:10]
+		codes = append(codes, code)
+		hashes = append(hashes, util.HashToken(normalizeRecoveryCode(code)))
+	}
+	if err := rep().WithCtx(ctx).ReplaceRecoveryCodes(user.ID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifyTOTP 校验验证码，同一验证码只能使用一次
+func verifyTOTP(user *model.User, code string) bool {
+	secret, err := mfaSecret(user)
+	if err != nil {
+		logger.Error("解密两步验证密钥错误", err)
+		return false
+	}
+	return verifyTOTPSecret(user.UserName, secret, code)
+}
+
+func verifyTOTPSecret(username, secret, code string) bool {
+	skew := conf.GetConfig().Mfa.Skew
+	counter, ok := util.ValidateTOTP(secret, strings.TrimSpace(code), time.Now(), skew)
+	if !ok {
+		return false
+	}
+	ok, err := redis().UseTOTPCounter(username, counter, time.Duration(2*skew+1)*util.TOTPPeriod*time.Second)
+	if err != nil {
+		logger.Error("记录验证码使用错误", err)
+		return false
+	}
+	return ok
+}
+
+// mfaPending 密码校验通过后颁发两步验证Token
+func mfaPending(user *model.User, device string, client *req.Client) *data.Response {
+	token, err := util.RandomToken(32)
+	if err != nil {
+		logger.Error("生成两步验证Token错误", err)
+		return data.NewErrorResponse(10000, "颁发Token错误")
+	}
+
+	expire := conf.GetConfig().Mfa.LoginExpire
+	err = redis().SetMfaLogin(util.HashToken(token), &cache.MfaLogin{
+		UserName:  user.UserName,
+		Device:    device,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+	}, expire)
+	if err != nil {
+		logger.Error("存储两步验证Token错误", err)
+		return data.NewErrorResponse(10000, "颁发Token错误")
+	}
+
+	return data.NewDataResponse(&data.MfaPendingReq{
+		MfaRequired: true,
+		MfaToken:    token,
+		MfaExpire:   expire.Milliseconds(),
+	})
+}
+
+// @Description 两步验证登录请求
+type MfaLoginReq struct {
+	// 两步验证Token
+	MfaToken string `form:"mfa_token" json:"mfa_token" binding:"required"`
+	// 认证器验证码
+	Code string `form:"code" json:"code" binding:"required_without=RecoveryCode"`
+	// 恢复码，无法使用认证器时使用
+	RecoveryCode string `form:"recovery_code" json:"recovery_code"`
+}
+
+// MfaLogin 校验验证码或恢复码完成登录
+func MfaLogin(service *MfaLoginReq, client *req.Client) *data.Response {
+	hash := util.HashToken(service.MfaToken)
+	login, attempts, err := redis().GetMfaLogin(hash)
+	if err != nil {
+		if err != cache.Nil {
+			logger.Error("查询两步验证Token错误", err)
+		}
+		return data.NewErrorResponse(20021, "两步验证已过期，请重新登录")
+	}
+	if attempts > conf.GetConfig().Mfa.MaxAttempts {
+		_ = redis().DelMfaLogin(hash)
+		return data.NewErrorResponse(20021, "两步验证已过期，请重新登录")
+	}
+
+	user, err := rep().GetUser(login.UserName)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	loginClient := &req.Client{IP: login.IP, UserAgent: login.UserAgent}
+	// 验证码错误与密码错误共用失败次数，避免重复密码步骤暴力破解验证码
+	if resp := loginThrottled(cache.LoginUserSubject(user.UserName), cache.LoginIPSubject(client.IP)); resp != nil {
+		recordLogin(user, user.UserName, login.Device, loginClient, model.LoginThrottled)
+		return resp
+	}
+
+	var ok bool
+	if service.RecoveryCode != "" {
+		ok, err = rep().As(user.UserName).UseRecoveryCode(user.ID, util.HashToken(normalizeRecoveryCode(service.RecoveryCode)))
+		if err != nil {
+			logger.Error("使用恢复码错误", err)
+		}
+	} else {
+		ok = verifyTOTP(user, service.Code)
+	}
+	if !ok {
+		loginFailed(user.UserName, client.IP)
+		recordLogin(user, user.UserName, login.Device, loginClient, model.LoginMfaFailed)
+		return data.NewErrorResponse(20022, "验证码错误")
+	}
+
+	if err = redis().DelMfaLogin(hash); err != nil {
+		logger.Error("删除两步验证Token错误", err)
+	}
+	return loginSuccess(user, login.Device, loginClient)
+}
+
+// MfaSetup 生成两步验证密钥，需调用MfaEnable确认后生效
+func MfaSetup(username string) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	if user.MfaEnabled {
+		return data.NewErrorResponse(20023, "已开启两步验证")
+	}
+
+	cfg := conf.GetConfig()
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		logger.Error("生成两步验证密钥错误", err)
+		return data.NewErrorResponse(20024, "生成密钥失败")
+	}
+	encrypted, err := util.Encrypt(secret, cfg.Server.EncryptKey)
+	if err == nil {
+		err = redis().SetMfaPending(username, encrypted, 10*time.Minute)
+	}
+	if err != nil {
+		logger.Error("存储两步验证密钥错误", err)
+		return data.NewErrorResponse(20024, "生成密钥失败")
+	}
+
+	return data.NewDataResponse(&data.MfaSetupReq{
+		Secret: secret,
+		URI:    util.TOTPURI(cfg.Mfa.Issuer, username, secret),
+	})
+}
+
+// @Description 两步验证码请求
+type MfaCodeReq struct {
+	// 认证器验证码
+	Code string `form:"code" json:"code" binding:"required,len=6,numeric"`
+}
+
+// MfaEnable 校验认证器验证码后开启两步验证，并返回恢复码
+func MfaEnable(ctx context.Context, username string, service *MfaCodeReq) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	if user.MfaEnabled {
+		return data.NewErrorResponse(20023, "已开启两步验证")
+	}
+
+	encrypted, err := redis().GetMfaPending(username)
+	if err != nil {
+		return data.NewErrorResponse(20025, "请先生成两步验证密钥")
+	}
+	secret, err := util.Decrypt(encrypted, conf.GetConfig().Server.EncryptKey)
+	if err != nil {
+		logger.Error("解密两步验证密钥错误", err)
+		return data.NewErrorResponse(20025, "请先生成两步验证密钥")
+	}
+	if !verifyTOTPSecret(username, secret, service.Code) {
+		return data.NewErrorResponse(20022, "验证码错误")
+	}
+
+	err = rep().WithCtx(ctx).Model(user).Updates(map[string]interface{}{
+		"mfa_enabled": true,
+		"mfa_secret":  encrypted,
+	}).Error
+	if err != nil {
+		logger.Error("开启两步验证错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "开启两步验证失败")
+	}
+	_ = redis().DelMfaPending(username)
+
+	codes, err := newRecoveryCodes(ctx, user)
+	if err != nil {
+		logger.Error("生成恢复码错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "生成恢复码失败")
+	}
+	return data.NewDataResponse(&data.RecoveryCodesReq{Codes: codes})
+}
+
+// @Description 关闭两步验证请求
+type MfaDisableReq struct {
+	// 密码
+	Password string `form:"password" json:"password" binding:"required"`
+	// 认证器验证码
+	Code string `form:"code" json:"code" binding:"required,len=6,numeric"`
+}
+
+// MfaDisable 校验密码及验证码后关闭两步验证
+func MfaDisable(ctx context.Context, username string, service *MfaDisableReq) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	if !user.MfaEnabled {
+		return data.NewErrorResponse(20026, "未开启两步验证")
+	}
+	if !user.CheckPassword(service.Password) || !verifyTOTP(user, service.Code) {
+		return data.NewErrorResponse(20022, "密码或验证码错误")
+	}
+
+	err = rep().WithCtx(ctx).Model(user).Updates(map[string]interface{}{
+		"mfa_enabled": false,
+		"mfa_secret":  "",
+	}).Error
+	if err == nil {
+		err = rep().WithCtx(ctx).DeleteRecoveryCodes(user.ID)
+	}
+	if err != nil {
+		logger.Error("关闭两步验证错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "关闭两步验证失败")
+	}
+	return data.NewSuccessResponse("已关闭两步验证")
+}
+
+// MfaRecoveryCodes 校验验证码后重新生成恢复码
+func MfaRecoveryCodes(ctx context.Context, username string, service *MfaCodeReq) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	if !user.MfaEnabled {
+		return data.NewErrorResponse(20026, "未开启两步验证")
+	}
+	if !verifyTOTP(user, service.Code) {
+		return data.NewErrorResponse(20022, "验证码错误")
+	}
+
+	codes, err := newRecoveryCodes(ctx, user)
+	if err != nil {
+		logger.Error("生成恢复码错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "生成恢复码失败")
+	}
+	return data.NewDataResponse(&data.RecoveryCodesReq{Codes: codes})
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+	"singo/cache"
+	"singo/conf"
+	"singo/data"
+	"singo/model"
+	"singo/testutil"
+	"singo/util"
+	"testing"
+	"time"
+)
+
+// createMfaUser 创建已开启两步验证的用户，返回TOTP密钥
+func createMfaUser(t *testing.T, username string) string {
+	t.Helper()
+	user := createTestUser(t, username, username+"@example.com", "Gz8#kq2Lmv")
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := util.Encrypt(secret, conf.GetConfig().Server.EncryptKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = rep().Model(user).Updates(map[string]interface{}{"mfa_enabled": true, "mfa_secret": encrypted}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}
+
+// wrongTOTP 返回当前时间窗口内均无效的验证码
+func wrongTOTP(t *testing.T, secret string) string {
+	t.Helper()
+	valid := map[string]bool{}
+	counter := time.Now().Unix() / util.TOTPPeriod
+	for i := counter - 2; i <= counter+2; i++ {
+		code, err := util.TOTPCode(secret, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		valid[code] = true
+	}
+	for i := 0; ; i++ {
+		if code := fmt.Sprintf("%06d", i); !valid[code] {
+			return code
+		}
+	}
+}
+
+// mfaToken 使用密码登录并返回两步验证Token
+func mfaToken(t *testing.T, username string) string {
+	t.Helper()
+	resp := Login(&UserLoginReq{UserName: username, Password: "Gz8#kq2Lmv"}, testClient)
+	pending, ok := resp.Data.(*data.MfaPendingReq)
+	if !ok {
+		t.Fatalf("密码登录 = %+v", resp)
+	}
+	return pending.MfaToken
+}
+
+func TestMfaLoginFailureThrottled(t *testing.T) {
+	testutil.Setup(t)
+	secret := createMfaUser(t, "alice01")
+	wrong := wrongTOTP(t, secret)
+
+	// 重新执行密码步骤不会清除验证码错误的次数
+	free := conf.GetConfig().Login.FreeAttempts
+	for i := int64(0); i < free; i++ {
+		token := mfaToken(t, "alice01")
+		if resp := MfaLogin(&MfaLoginReq{MfaToken: token, Code: wrong}, testClient); resp.ErrCode != 20022 {
+			t.Fatalf("第%d次错误验证码 = %+v", i+1, resp)
+		}
+	}
+	token := mfaToken(t, "alice01")
+	if resp := MfaLogin(&MfaLoginReq{MfaToken: token, Code: wrong}, testClient); resp.ErrCode != 20022 {
+		t.Fatalf("错误验证码 = %+v", resp)
+	}
+
+	// 超过免延迟次数后密码登录及两步验证均被限制
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, testClient); resp.ErrCode != 20010 {
+		t.Errorf("限制中密码登录 = %+v", resp)
+	}
+	code, err := util.TOTPCode(secret, time.Now().Unix()/util.TOTPPeriod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := MfaLogin(&MfaLoginReq{MfaToken: token, Code: code}, testClient); resp.ErrCode != 20010 {
+		t.Errorf("限制中两步验证 = %+v", resp)
+	}
+	var history model.LoginHistory
+	if err = rep().Where("user_name = ?", "alice01").Last(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if history.Reason != model.LoginThrottled {
+		t.Errorf("登录历史 = %+v", history)
+	}
+}
+
+func TestMfaLoginClearsFailures(t *testing.T) {
+	testutil.Setup(t)
+	secret := createMfaUser(t, "alice01")
+
+	token := mfaToken(t, "alice01")
+	if resp := MfaLogin(&MfaLoginReq{MfaToken: token, Code: wrongTOTP(t, secret)}, testClient); resp.ErrCode != 20022 {
+		t.Fatalf("错误验证码 = %+v", resp)
+	}
+	count, err := redis().GetLoginFail(cache.LoginUserSubject("alice01"))
+	if err != nil || count != 1 {
+		t.Fatalf("失败次数 = %d, %v", count, err)
+	}
+
+	code, err := util.TOTPCode(secret, time.Now().Unix()/util.TOTPPeriod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := MfaLogin(&MfaLoginReq{MfaToken: token, Code: code}, testClient); !resp.Success {
+		t.Fatalf("两步验证 = %+v", resp)
+	}
+	// 两步验证通过后才清除失败次数
+	if count, _ = redis().GetLoginFail(cache.LoginUserSubject("alice01")); count != 0 {
+		t.Errorf("登录成功后失败次数 = %d", count)
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"singo/data"
+	"singo/logger"
+	"singo/model"
+	"time"
+)
+
+// @Description 封禁用户请求
+type UserSuspendReq struct {
+	// 用户名
+	UserName string `form:"user_name" json:"user_name" binding:"required,min=5,max=30"`
+	// 封禁原因
+	Reason string `form:"reason" json:"reason" binding:"required,max=200"`
+	// 封禁到期时间(毫秒时间戳)，为空表示永久封禁
+	ExpireAt int64 `form:"expire_at" json:"expire_at"`
+}
+
+// SuspendUser 封禁用户并撤销其全部会话
+func SuspendUser(ctx context.Context, service *UserSuspendReq) *data.Response {
+	user, err := rep().GetUser(service.UserName)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+
+	var until *time.Time
+	var expire time.Duration
+	if service.ExpireAt > 0 {
+		t := time.UnixMilli(service.ExpireAt)
+		if expire = time.Until(t); expire <= 0 {
+			return data.ParamErr("封禁到期时间必须晚于当前时间")
+		}
+		until = &t
+	}
+
+	err = rep().WithCtx(ctx).Model(user).Updates(map[string]interface{}{
+		"status":          model.Suspend,
+		"suspend_reason":  service.Reason,
+		"suspended_until": until,
+	}).Error
+	if err != nil {
+		logger.Error("封禁用户错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "封禁失败")
+	}
+
+	if err = redis().SetSuspended(user.UserName, expire); err != nil {
+		logger.Error("设置封禁标记错误", err)
+	}
+	if err = redis().DelUserSessions(user.UserName); err != nil {
+		logger.Error("删除会话错误", err)
+	}
+	return data.NewSuccessResponse("封禁成功")
+}
+
+// @Description 用户管理请求
+type UserModerateReq struct {
+	// 用户名
+	UserName string `form:"user_name" json:"user_name" binding:"required,min=5,max=30"`
+}
+
+// reactivate 解除封禁
+func reactivate(ctx context.Context, user *model.User) error {
+	err := rep().WithCtx(ctx).Model(user).Updates(map[string]interface{}{
+		"status":          model.Active,
+		"suspend_reason":  "",
+		"suspended_until": nil,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return redis().DelSuspended(user.UserName)
+}
+
+// ReactivateUser 管理员解除封禁
+func ReactivateUser(ctx context.Context, service *UserModerateReq) *data.Response {
+	user, err := rep().GetUser(service.UserName)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	if user.Status != model.Suspend {
+		return data.NewErrorResponse(20020, "用户未被封禁")
+	}
+
+	if err = reactivate(ctx, user); err != nil {
+		logger.Error("解除封禁错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "解除封禁失败")
+	}
+	return data.NewSuccessResponse("解除封禁成功")
+}
+
+// DeleteUser 管理员删除用户(软删除)并撤销其全部会话及OAuth2 Token
+func DeleteUser(ctx context.Context, service *UserModerateReq) *data.Response {
+	user, err := rep().GetUser(service.UserName)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+
+	if err = rep().WithCtx(ctx).Delete(user).Error; err != nil {
+		logger.Error("删除用户错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "删除用户失败")
+	}
+	revokeUserTokens(user.UserName)
+	return data.NewSuccessResponse("删除成功")
+}
+
+// suspendedResponse 封禁提示
+func suspendedResponse(user *model.User) *data.Response {
+	msg := "账号已被封禁"
+	if user.SuspendReason != "" {
+		msg = fmt.Sprintf("%s，原因：%s", msg, user.SuspendReason)
+	}
+	if user.SuspendedUntil != nil {
+		msg = fmt.Sprintf("%s，解封时间：%s", msg, user.SuspendedUntil.Format("2006-01-02 15:04:05"))
+	}
+	return data.NewErrorResponse(20013, msg)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"singo/data"
+	"singo/model"
+	"singo/testutil"
+	"testing"
+)
+
+// unscopedUser 查询包括已删除的用户
+func unscopedUser(t *testing.T, id uint) *model.User {
+	t.Helper()
+	var user model.User
+	if err := rep().Unscoped().First(&user, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &user
+}
+
+func TestOperatorFromContext(t *testing.T) {
+	testutil.Setup(t)
+	alice := createTestUser(t, "alice01", "alice@example.com", "")
+
+	if resp := SuspendUser(userCtx("admin01"), &UserSuspendReq{UserName: "alice01", Reason: "test"}); !resp.Success {
+		t.Fatalf("SuspendUser() = %+v", resp)
+	}
+	if user := unscopedUser(t, alice.ID); user.UpdatedBy != "admin01" {
+		t.Errorf("封禁后UpdatedBy = %q, want admin01", user.UpdatedBy)
+	}
+
+	// 软删除记录删除的操作人
+	if resp := DeleteUser(userCtx("admin02"), &UserModerateReq{UserName: "alice01"}); !resp.Success {
+		t.Fatalf("DeleteUser() = %+v", resp)
+	}
+	if user := unscopedUser(t, alice.ID); !user.DeletedAt.Valid || user.UpdatedBy != "admin02" {
+		t.Errorf("删除后的用户 = %+v", user)
+	}
+
+	resp := CreateOAuthClient(userCtx("admin03"), &OAuthClientCreateReq{
+		Name:         "test",
+		RedirectURIs: []string{"http://localhost/callback"},
+	})
+	if !resp.Success {
+		t.Fatalf("CreateOAuthClient() = %+v", resp)
+	}
+	if client := resp.Data.(*data.OAuthClientReq); client.CreatedBy != "admin03" {
+		t.Errorf("客户端CreatedBy = %q, want admin03", client.CreatedBy)
+	}
+}
+
+func TestOperatorSelfService(t *testing.T) {
+	testutil.Setup(t)
+
+	// 未登录的注册流程记录用户本人
+	resp := smsLogin(t, "13800138000")
+	if !resp.Success {
+		t.Fatalf("短信登录 = %+v", resp)
+	}
+	user := unscopedUser(t, resp.Data.(*data.UserReq).ID)
+	if user.CreatedBy != user.UserName {
+		t.Errorf("注册后CreatedBy = %q, want %s", user.CreatedBy, user.UserName)
+	}
+
+	if resp = DeleteAccount(userCtx(user.UserName), user.UserName, &UserDeleteReq{}); !resp.Success {
+		t.Fatalf("DeleteAccount() = %+v", resp)
+	}
+	if deleted := unscopedUser(t, user.ID); deleted.UpdatedBy != user.UserName {
+		t.Errorf("注销后UpdatedBy = %q, want %s", deleted.UpdatedBy, user.UserName)
+	}
+}
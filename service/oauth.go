@@ -0,0 +1,529 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"singo/cache"
+	"singo/conf"
+	"singo/data"
+	"singo/logger"
+	"singo/model"
+	"singo/util"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// oauthAccessToken 访问Token类型
+	oauthAccessToken = "access_token"
+	// oauthRefreshToken 刷新Token类型
+	oauthRefreshToken = "refresh_token"
+)
+
+// @Description OAuth2授权请求
+type OAuthAuthorizeReq struct {
+	// 响应类型，固定为code
+	ResponseType string `form:"response_type" json:"response_type" binding:"required,eq=code"`
+	// 客户端编号
+	ClientID string `form:"client_id" json:"client_id" binding:"required"`
+	// 回调地址
+	RedirectURI string `form:"redirect_uri" json:"redirect_uri" binding:"required"`
+	// 授权范围，空格分隔，为空时申请客户端全部范围
+	Scope string `form:"scope" json:"scope"`
+	// 原样返回给客户端的状态值
+	State string `form:"state" json:"state"`
+	// PKCE挑战码，公开客户端必填
+	CodeChallenge string `form:"code_challenge" json:"code_challenge" binding:"omitempty,min=43,max=128"`
+	// PKCE挑战方式 S256/plain
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method" binding:"omitempty,oneof=S256 plain"`
+}
+
+// @Description OAuth2授权确认请求
+type OAuthApproveReq struct {
+	OAuthAuthorizeReq
+	// 是否同意授权
+	Approve bool `form:"approve" json:"approve"`
+}
+
+// checkScopes 校验申请的授权范围，为空时返回客户端全部范围
+func checkScopes(client *model.OAuthClient, scope string) ([]string, bool) {
+	allowed := client.ScopeList()
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return allowed, true
+	}
+	for _, item := range requested {
+		found := false
+		for _, a := range allowed {
+			if a == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return requested, true
+}
+
+// containsScopes 判断已授权范围是否覆盖申请的范围
+func containsScopes(granted string, requested []string) bool {
+	set := map[string]bool{}
+	for _, item := range strings.Fields(granted) {
+		set[item] = true
+	}
+	for _, item := range requested {
+		if !set[item] {
+			return false
+		}
+	}
+	return true
+}
+
+// validateAuthorize 校验授权请求
+func validateAuthorize(service *OAuthAuthorizeReq) (*model.OAuthClient, []string, *data.Response) {
+	client, err := rep().GetOAuthClient(service.ClientID)
+	if err != nil {
+		return nil, nil, data.NewErrorResponse(40101, "客户端不存在")
+	}
+	if !client.AllowRedirect(service.RedirectURI) {
+		return nil, nil, data.NewErrorResponse(40102, "回调地址未注册")
+	}
+	if !client.Confidential && service.CodeChallenge == "" {
+		return nil, nil, data.NewErrorResponse(40103, "公开客户端必须使用PKCE")
+	}
+	scopes, ok := checkScopes(client, service.Scope)
+	if !ok {
+		return nil, nil, data.NewErrorResponse(40104, "授权范围无效")
+	}
+	return client, scopes, nil
+}
+
+// redirectWith 在回调地址上追加参数
+func redirectWith(redirectURI string, params map[string]string) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := u.Query()
+	for k, v := range params {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// AuthorizeInfo 返回授权确认页面所需信息
+func AuthorizeInfo(username string, service *OAuthAuthorizeReq) *data.Response {
+	client, scopes, resp := validateAuthorize(service)
+	if resp != nil {
+		return resp
+	}
+
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+
+	consented := false
+	if consent, err := rep().GetOAuthConsent(user.ID, client.ClientID); err == nil {
+		consented = containsScopes(consent.Scopes, scopes)
+	}
+	return data.NewDataResponse(&data.OAuthConsentReq{
+		ClientID:   client.ClientID,
+		ClientName: client.Name,
+		Scopes:     scopes,
+		Consented:  consented,
+	})
+}
+
+// Authorize 用户确认授权，生成授权码并返回回调地址
+func Authorize(ctx context.Context, username string, service *OAuthApproveReq) *data.Response {
+	client, scopes, resp := validateAuthorize(&service.OAuthAuthorizeReq)
+	if resp != nil {
+		return resp
+	}
+
+	if !service.Approve {
+		return data.NewDataResponse(&data.OAuthRedirectReq{
+			RedirectURI: redirectWith(service.RedirectURI, map[string]string{
+				"error": "access_denied",
+				"state": service.State,
+			}),
+		})
+	}
+
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	scope := strings.Join(scopes, " ")
+	if err = rep().WithCtx(ctx).SaveOAuthConsent(user.ID, client.ClientID, scope); err != nil {
+		logger.Error("保存授权记录错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "授权失败")
+	}
+
+	code, err := util.RandomToken(32)
+	if err == nil {
+		err = redis().SetOAuthCode(util.HashToken(code), &cache.OAuthCode{
+			ClientID:            client.ClientID,
+			UserName:            user.UserName,
+			RedirectURI:         service.RedirectURI,
+			Scope:               scope,
+			CodeChallenge:       service.CodeChallenge,
+			CodeChallengeMethod: service.CodeChallengeMethod,
+		}, conf.GetConfig().OAuth.CodeExpire)
+	}
+	if err != nil {
+		logger.Error("生成授权码错误", err)
+		return data.NewErrorResponse(40105, "授权失败")
+	}
+
+	return data.NewDataResponse(&data.OAuthRedirectReq{
+		RedirectURI: redirectWith(service.RedirectURI, map[string]string{
+			"code":  code,
+			"state": service.State,
+		}),
+	})
+}
+
+// @Description OAuth2 Token请求
+type OAuthTokenReq struct {
+	// 授权类型 authorization_code/client_credentials/refresh_token
+	GrantType string `form:"grant_type" binding:"required"`
+	// 授权码
+	Code string `form:"code"`
+	// 回调地址，须与授权请求一致
+	RedirectURI string `form:"redirect_uri"`
+	// PKCE原始校验码
+	CodeVerifier string `form:"code_verifier"`
+	// 刷新Token
+	RefreshToken string `form:"refresh_token"`
+	// 授权范围
+	Scope string `form:"scope"`
+	// 客户端编号，也可使用HTTP Basic认证
+	ClientID string `form:"client_id"`
+	// 客户端密钥，也可使用HTTP Basic认证
+	ClientSecret string `form:"client_secret"`
+}
+
+// authenticateClient 校验客户端身份，公开客户端只校验编号
+func authenticateClient(clientID, secret string) (*model.OAuthClient, *data.OAuthError) {
+	if clientID == "" {
+		return nil, data.NewOAuthError(data.OAuthInvalidClient, "缺少客户端编号")
+	}
+	client, err := rep().GetOAuthClient(clientID)
+	if err != nil {
+		return nil, data.NewOAuthError(data.OAuthInvalidClient, "客户端认证失败")
+	}
+	if client.Confidential && subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(util.HashToken(secret))) != 1 {
+		return nil, data.NewOAuthError(data.OAuthInvalidClient, "客户端认证失败")
+	}
+	return client, nil
+}
+
+// issueOAuthToken 颁发访问Token，withRefresh为true时同时颁发刷新Token
+func issueOAuthToken(clientID, username, scope string, withRefresh bool) (*data.OAuthToken, *data.OAuthError) {
+	cfg := conf.GetConfig().OAuth
+	accessToken, err := util.RandomToken(32)
+	if err != nil {
+		logger.Error("生成Token错误", err)
+		return nil, data.NewOAuthError(data.OAuthServerError, "")
+	}
+	accessHash := util.HashToken(accessToken)
+	resp := &data.OAuthToken{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(cfg.AccessExpire.Seconds()),
+		Scope:       scope,
+	}
+
+	var refreshHash string
+	if withRefresh {
+		if resp.RefreshToken, err = util.RandomToken(32); err != nil {
+			logger.Error("生成Token错误", err)
+			return nil, data.NewOAuthError(data.OAuthServerError, "")
+		}
+		refreshHash = util.HashToken(resp.RefreshToken)
+		err = redis().SetOAuthToken(refreshHash, &cache.OAuthToken{
+			ClientID: clientID,
+			UserName: username,
+			Scope:    scope,
+			Type:     oauthRefreshToken,
+			Pair:     accessHash,
+			ExpireAt: time.Now().Add(cfg.RefreshExpire).Unix(),
+		}, cfg.RefreshExpire)
+		if err != nil {
+			logger.Error("存储Token错误", err)
+			return nil, data.NewOAuthError(data.OAuthServerError, "")
+		}
+	}
+
+	err = redis().SetOAuthToken(accessHash, &cache.OAuthToken{
+		ClientID: clientID,
+		UserName: username,
+		Scope:    scope,
+		Type:     oauthAccessToken,
+		Pair:     refreshHash,
+		ExpireAt: time.Now().Add(cfg.AccessExpire).Unix(),
+	}, cfg.AccessExpire)
+	if err != nil {
+		logger.Error("存储Token错误", err)
+		return nil, data.NewOAuthError(data.OAuthServerError, "")
+	}
+	return resp, nil
+}
+
+// oauthUserActive 判断Token所属用户是否存在且状态正常，客户端凭证模式的Token不属于用户
+func oauthUserActive(username string) bool {
+	if username == "" {
+		return true
+	}
+	user, err := rep().GetUser(username)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Error("查询用户错误", err)
+		}
+		return false
+	}
+	return checkStatus(user) == nil
+}
+
+// OAuthToken 按授权类型颁发Token
+func OAuthToken(service *OAuthTokenReq) (*data.OAuthToken, *data.OAuthError) {
+	client, oauthErr := authenticateClient(service.ClientID, service.ClientSecret)
+	if oauthErr != nil {
+		return nil, oauthErr
+	}
+
+	switch service.GrantType {
+	case "authorization_code":
+		return authorizationCodeGrant(client, service)
+	case "client_credentials":
+		return clientCredentialsGrant(client, service)
+	case "refresh_token":
+		return refreshTokenGrant(client, service)
+	default:
+		return nil, data.NewOAuthError(data.OAuthUnsupportedGrantType, "")
+	}
+}
+
+// authorizationCodeGrant 授权码模式
+func authorizationCodeGrant(client *model.OAuthClient, service *OAuthTokenReq) (*data.OAuthToken, *data.OAuthError) {
+	if service.Code == "" {
+		return nil, data.NewOAuthError(data.OAuthInvalidRequest, "缺少授权码")
+	}
+	code, err := redis().TakeOAuthCode(util.HashToken(service.Code))
+	if err != nil {
+		if err != cache.Nil {
+			logger.Error("查询授权码错误", err)
+		}
+		return nil, data.NewOAuthError(data.OAuthInvalidGrant, "授权码无效或已使用")
+	}
+	if code.ClientID != client.ClientID || code.RedirectURI != service.RedirectURI {
+		return nil, data.NewOAuthError(data.OAuthInvalidGrant, "授权码与客户端不匹配")
+	}
+	if code.CodeChallenge != "" && !util.VerifyPKCE(service.CodeVerifier, code.CodeChallenge, code.CodeChallengeMethod) {
+		return nil, data.NewOAuthError(data.OAuthInvalidGrant, "PKCE校验失败")
+	}
+	if !oauthUserActive(code.UserName) {
+		return nil, data.NewOAuthError(data.OAuthInvalidGrant, "用户已注销或被禁用")
+	}
+	return issueOAuthToken(client.ClientID, code.UserName, code.Scope, true)
+}
+
+// clientCredentialsGrant 客户端凭证模式，仅限机密客户端
+func clientCredentialsGrant(client *model.OAuthClient, service *OAuthTokenReq) (*data.OAuthToken, *data.OAuthError) {
+	if !client.Confidential {
+		return nil, data.NewOAuthError(data.OAuthUnauthorizedClient, "公开客户端不支持该授权类型")
+	}
+	scopes, ok := checkScopes(client, service.Scope)
+	if !ok {
+		return nil, data.NewOAuthError(data.OAuthInvalidScope, "")
+	}
+	return issueOAuthToken(client.ClientID, "", strings.Join(scopes, " "), false)
+}
+
+// refreshTokenGrant 刷新Token模式，旧的刷新Token及其访问Token同时作废
+func refreshTokenGrant(client *model.OAuthClient, service *OAuthTokenReq) (*data.OAuthToken, *data.OAuthError) {
+	hash := util.HashToken(service.RefreshToken)
+	token, err := redis().GetOAuthToken(hash)
+	if err != nil || token.Type != oauthRefreshToken || token.ClientID != client.ClientID {
+		return nil, data.NewOAuthError(data.OAuthInvalidGrant, "刷新Token无效")
+	}
+	if !oauthUserActive(token.UserName) {
+		if err = redis().DelOAuthToken(hash, token.Pair); err != nil {
+			logger.Error("撤销Token错误", err)
+		}
+		return nil, data.NewOAuthError(data.OAuthInvalidGrant, "用户已注销或被禁用")
+	}
+
+	scope := token.Scope
+	if service.Scope != "" {
+		requested := strings.Fields(service.Scope)
+		if !containsScopes(token.Scope, requested) {
+			return nil, data.NewOAuthError(data.OAuthInvalidScope, "")
+		}
+		scope = strings.Join(requested, " ")
+	}
+
+	if err = redis().DelOAuthToken(hash, token.Pair); err != nil {
+		logger.Error("撤销Token错误", err)
+		return nil, data.NewOAuthError(data.OAuthServerError, "")
+	}
+	return issueOAuthToken(client.ClientID, token.UserName, scope, true)
+}
+
+// @Description OAuth2 Token内省及撤销请求
+type OAuthTokenCheckReq struct {
+	// 待检查的Token
+	Token string `form:"token" binding:"required"`
+	// 客户端编号，也可使用HTTP Basic认证
+	ClientID string `form:"client_id"`
+	// 客户端密钥，也可使用HTTP Basic认证
+	ClientSecret string `form:"client_secret"`
+}
+
+// OAuthIntrospect 按RFC 7662返回Token状态
+// 机密客户端(如资源服务)可查询任意Token，公开客户端只能查询自己的Token
+func OAuthIntrospect(service *OAuthTokenCheckReq) (*data.OAuthIntrospection, *data.OAuthError) {
+	client, oauthErr := authenticateClient(service.ClientID, service.ClientSecret)
+	if oauthErr != nil {
+		return nil, oauthErr
+	}
+
+	inactive := &data.OAuthIntrospection{Active: false}
+	token, err := redis().GetOAuthToken(util.HashToken(service.Token))
+	if err != nil || token.ExpireAt < time.Now().Unix() {
+		return inactive, nil
+	}
+	if !client.Confidential && token.ClientID != client.ClientID {
+		return inactive, nil
+	}
+	// 客户端已删除的Token视为失效
+	if _, err = rep().GetOAuthClient(token.ClientID); err != nil {
+		return inactive, nil
+	}
+	// 用户已注销或被禁用的Token视为失效
+	if !oauthUserActive(token.UserName) {
+		return inactive, nil
+	}
+	return &data.OAuthIntrospection{
+		Active:    true,
+		Scope:     token.Scope,
+		ClientID:  token.ClientID,
+		Username:  token.UserName,
+		TokenType: token.Type,
+		Exp:       token.ExpireAt,
+	}, nil
+}
+
+// OAuthRevoke 按RFC 7009撤销Token，Token不存在时同样视为成功
+func OAuthRevoke(service *OAuthTokenCheckReq) *data.OAuthError {
+	client, oauthErr := authenticateClient(service.ClientID, service.ClientSecret)
+	if oauthErr != nil {
+		return oauthErr
+	}
+
+	hash := util.HashToken(service.Token)
+	token, err := redis().GetOAuthToken(hash)
+	if err != nil || token.ClientID != client.ClientID {
+		return nil
+	}
+	if err = redis().DelOAuthToken(hash, token.Pair); err != nil {
+		logger.Error("撤销Token错误", err)
+		return data.NewOAuthError(data.OAuthServerError, "")
+	}
+	return nil
+}
+
+// @Description OAuth2客户端注册请求
+type OAuthClientCreateReq struct {
+	// 名称
+	Name string `form:"name" json:"name" binding:"required,min=2,max=100"`
+	// 回调地址
+	RedirectURIs []string `form:"redirect_uris" json:"redirect_uris" binding:"required,min=1,dive,url"`
+	// 允许申请的授权范围
+	Scopes []string `form:"scopes" json:"scopes" binding:"required,min=1,dive,min=1,max=50"`
+	// 是否为机密客户端
+	Confidential bool `form:"confidential" json:"confidential"`
+}
+
+// CreateOAuthClient 注册OAuth2客户端，机密客户端的密钥仅返回一次
+func CreateOAuthClient(ctx context.Context, service *OAuthClientCreateReq) *data.Response {
+	for _, scope := range service.Scopes {
+		if strings.ContainsAny(scope, " \t") {
+			return data.ParamErr("授权范围不能包含空白字符")
+		}
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		logger.Error("生成客户端编号错误", err)
+		return data.NewErrorResponse(40106, "注册客户端失败")
+	}
+
+	client := model.OAuthClient{
+		ClientID:     hex.EncodeToString(b),
+		Name:         service.Name,
+		RedirectURIs: strings.Join(service.RedirectURIs, " "),
+		Scopes:       strings.Join(service.Scopes, " "),
+		Confidential: service.Confidential,
+	}
+
+	var secret string
+	if service.Confidential {
+		var err error
+		if secret, err = util.RandomToken(32); err != nil {
+			logger.Error("生成客户端密钥错误", err)
+			return data.NewErrorResponse(40106, "注册客户端失败")
+		}
+		client.SecretHash = util.HashToken(secret)
+	}
+
+	if err := rep().WithCtx(ctx).Create(&client).Error; err != nil {
+		logger.Error("注册客户端错误", err)
+		return data.NewErrorResponse(40106, "注册客户端失败")
+	}
+
+	resp := data.BuildOAuthClient(&client)
+	resp.ClientSecret = secret
+	return data.NewDataResponse(resp)
+}
+
+// ListOAuthClients 获取全部OAuth2客户端
+func ListOAuthClients() *data.Response {
+	clients, err := rep().GetOAuthClients()
+	if err != nil {
+		logger.Error("查询客户端错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "查询客户端失败")
+	}
+	return data.NewDataResponse(data.BuildOAuthClients(clients))
+}
+
+// DeleteOAuthClient 删除OAuth2客户端及其授权记录，已颁发的Token在过期后失效
+func DeleteOAuthClient(ctx context.Context, clientID string) *data.Response {
+	res := rep().WithCtx(ctx).Where("client_id = ?", clientID).Delete(&model.OAuthClient{})
+	if res.Error != nil {
+		logger.Error("删除客户端错误", res.Error)
+		return data.NewErrorResponse(data.CodeDBError, "删除客户端失败")
+	}
+	if res.RowsAffected == 0 {
+		return data.NewErrorResponse(40101, "客户端不存在")
+	}
+	if err := rep().WithCtx(ctx).Where("client_id = ?", clientID).Delete(&model.OAuthConsent{}).Error; err != nil {
+		logger.Error("删除授权记录错误", err)
+	}
+	return data.NewSuccessResponse("删除成功")
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"singo/data"
+	"singo/testutil"
+	"singo/util"
+	"testing"
+)
+
+// createTestOAuthClient 创建机密客户端，返回客户端编号及密钥
+func createTestOAuthClient(t *testing.T) (string, string) {
+	t.Helper()
+	resp := CreateOAuthClient(userCtx("admin"), &OAuthClientCreateReq{
+		Name:         "test",
+		RedirectURIs: []string{"http://localhost/callback"},
+		Scopes:       []string{"profile"},
+		Confidential: true,
+	})
+	if !resp.Success {
+		t.Fatalf("CreateOAuthClient() = %+v", resp)
+	}
+	client := resp.Data.(*data.OAuthClientReq)
+	return client.ClientID, client.ClientSecret
+}
+
+func introspect(clientID, secret, token string) bool {
+	result, oauthErr := OAuthIntrospect(&OAuthTokenCheckReq{Token: token, ClientID: clientID, ClientSecret: secret})
+	return oauthErr == nil && result.Active
+}
+
+func refresh(clientID, secret, token string) *data.OAuthError {
+	_, oauthErr := OAuthToken(&OAuthTokenReq{
+		GrantType:    "refresh_token",
+		RefreshToken: token,
+		ClientID:     clientID,
+		ClientSecret: secret,
+	})
+	return oauthErr
+}
+
+func TestOAuthTokensRevokedOnDelete(t *testing.T) {
+	testutil.Setup(t)
+	createTestUser(t, "alice01", "alice@example.com", "Gz8#kq2Lmv")
+	clientID, secret := createTestOAuthClient(t)
+
+	token, oauthErr := issueOAuthToken(clientID, "alice01", "profile", true)
+	if oauthErr != nil {
+		t.Fatalf("issueOAuthToken() = %+v", oauthErr)
+	}
+	if !introspect(clientID, secret, token.AccessToken) {
+		t.Fatal("新颁发的Token应有效")
+	}
+
+	if resp := DeleteAccount(userCtx("alice01"), "alice01", &UserDeleteReq{Password: "Gz8#kq2Lmv"}); !resp.Success {
+		t.Fatalf("DeleteAccount() = %+v", resp)
+	}
+	if introspect(clientID, secret, token.AccessToken) {
+		t.Error("注销后访问Token仍有效")
+	}
+	if oauthErr = refresh(clientID, secret, token.RefreshToken); oauthErr == nil || oauthErr.Error != data.OAuthInvalidGrant {
+		t.Errorf("注销后刷新Token = %+v, want invalid_grant", oauthErr)
+	}
+	for _, raw := range []string{token.AccessToken, token.RefreshToken} {
+		if _, err := redis().GetOAuthToken(util.HashToken(raw)); err == nil {
+			t.Error("注销后Token仍存在")
+		}
+	}
+}
+
+func TestOAuthTokensRejectedForSuspendedUser(t *testing.T) {
+	testutil.Setup(t)
+	createTestUser(t, "alice01", "alice@example.com", "")
+	clientID, secret := createTestOAuthClient(t)
+
+	token, oauthErr := issueOAuthToken(clientID, "alice01", "profile", true)
+	if oauthErr != nil {
+		t.Fatalf("issueOAuthToken() = %+v", oauthErr)
+	}
+	if resp := SuspendUser(userCtx("admin"), &UserSuspendReq{UserName: "alice01", Reason: "test"}); !resp.Success {
+		t.Fatalf("SuspendUser() = %+v", resp)
+	}
+
+	if introspect(clientID, secret, token.AccessToken) {
+		t.Error("封禁后访问Token仍有效")
+	}
+	if oauthErr = refresh(clientID, secret, token.RefreshToken); oauthErr == nil {
+		t.Error("封禁后仍可刷新Token")
+	}
+}
+
+func TestOAuthRefresh(t *testing.T) {
+	testutil.Setup(t)
+	createTestUser(t, "alice01", "alice@example.com", "")
+	clientID, secret := createTestOAuthClient(t)
+
+	token, oauthErr := issueOAuthToken(clientID, "alice01", "profile", true)
+	if oauthErr != nil {
+		t.Fatalf("issueOAuthToken() = %+v", oauthErr)
+	}
+	if oauthErr = refresh(clientID, secret, token.RefreshToken); oauthErr != nil {
+		t.Errorf("刷新Token = %+v", oauthErr)
+	}
+	// 刷新后旧Token作废
+	if introspect(clientID, secret, token.AccessToken) {
+		t.Error("刷新后旧访问Token仍有效")
+	}
+}
+
+func TestOAuthTokensRevokedOnPasswordChange(t *testing.T) {
+	testutil.Setup(t)
+	createTestUser(t, "alice01", "alice@example.com", "Gz8#kq2Lmv")
+	clientID, secret := createTestOAuthClient(t)
+
+	token, oauthErr := issueOAuthToken(clientID, "alice01", "profile", true)
+	if oauthErr != nil {
+		t.Fatalf("issueOAuthToken() = %+v", oauthErr)
+	}
+	resp := ChangePassword(userCtx("alice01"), "alice01", &PasswordChangeReq{
+		OldPassword: "Gz8#kq2Lmv", Password: "Hq7$wn3Xpb", PasswordConfirm: "Hq7$wn3Xpb",
+	})
+	if !resp.Success {
+		t.Fatalf("ChangePassword() = %+v", resp)
+	}
+
+	if introspect(clientID, secret, token.AccessToken) {
+		t.Error("修改密码后访问Token仍有效")
+	}
+	if oauthErr = refresh(clientID, secret, token.RefreshToken); oauthErr == nil {
+		t.Error("修改密码后仍可刷新Token")
+	}
+}
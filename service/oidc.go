@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"singo/cache"
+	"singo/data"
+	"singo/logger"
+	"singo/model"
+	"singo/oidc"
+	"singo/req"
+	"singo/util"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// oidcStateExpire 跳转到身份提供方后完成登录的时限
+const oidcStateExpire = 10 * time.Minute
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// @Description 外部登录请求
+type OidcLoginReq struct {
+	// 设备名称
+	Device string `form:"device" json:"device" binding:"max=50"`
+	// 是否恢复注销宽限期内的账号
+	Restore bool `form:"restore" json:"restore"`
+}
+
+// OidcAuthURL 生成跳转到身份提供方的授权地址，linkUser非空时为绑定模式
+func OidcAuthURL(providerName, linkUser string, service *OidcLoginReq) *data.Response {
+	provider, ok := oidc.GetProvider(providerName)
+	if !ok {
+		return data.NewErrorResponse(20030, "身份提供方不存在")
+	}
+
+	state, err := util.RandomToken(24)
+	if err != nil {
+		logger.Error("生成登录状态错误", err)
+		return data.NewErrorResponse(20031, "外部登录失败")
+	}
+	nonce, _ := util.RandomToken(16)
+	verifier, _ := util.RandomToken(32)
+
+	authURL, err := provider.AuthURL(state, nonce, util.PKCEChallenge(verifier))
+	if err != nil {
+		logger.Error("获取身份提供方配置错误", err)
+		return data.NewErrorResponse(20031, "外部登录失败")
+	}
+
+	err = redis().SetOidcState(util.HashToken(state), &cache.OidcState{
+		Provider: provider.Name(),
+		Nonce:    nonce,
+		Verifier: verifier,
+		LinkUser: linkUser,
+		Device:   service.Device,
+		Restore:  service.Restore,
+	}, oidcStateExpire)
+	if err != nil {
+		logger.Error("存储登录状态错误", err)
+		return data.NewErrorResponse(20031, "外部登录失败")
+	}
+	return data.NewDataResponse(&data.OidcRedirectReq{AuthURL: authURL})
+}
+
+// @Description 外部登录回调请求
+type OidcCallbackReq struct {
+	// 授权码
+	Code string `form:"code" binding:"required"`
+	// 登录状态
+	State string `form:"state" binding:"required"`
+}
+
+// OidcCallback 处理身份提供方回调，登录、注册或绑定外部身份
+func OidcCallback(providerName string, service *OidcCallbackReq, client *req.Client) *data.Response {
+	state, err := redis().TakeOidcState(util.HashToken(service.State))
+	if err != nil || state.Provider != providerName {
+		return data.NewErrorResponse(20032, "登录状态无效或已过期")
+	}
+	provider, ok := oidc.GetProvider(providerName)
+	if !ok {
+		return data.NewErrorResponse(20030, "身份提供方不存在")
+	}
+
+	claims, err := provider.Exchange(service.Code, state.Verifier, state.Nonce)
+	if err != nil {
+		logger.Error("校验外部身份错误", err)
+		return data.NewErrorResponse(20031, "外部登录失败")
+	}
+
+	identity, err := rep().GetIdentity(providerName, claims.Subject)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 查询不到记录时gorm仍会分配空结构体，这里置空表示未绑定
+		identity = nil
+	} else if err != nil {
+		logger.Error("查询外部身份错误", err)
+		return data.NewErrorResponse(20031, "外部登录失败")
+	}
+
+	if state.LinkUser != "" {
+		return linkIdentity(state.LinkUser, identity, providerName, claims)
+	}
+
+	var user *model.User
+	if identity != nil {
+		err = rep().Preload("Roles").First(&user, identity.UserID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 账号在注销宽限期内，登录时指定恢复则撤销注销
+			if user, err = rep().GetPendingDeletionUser("id = ?", identity.UserID); err == nil {
+				if !state.Restore {
+					recordLogin(user, user.UserName, state.Device, client, model.LoginPendingDeletion)
+					return pendingDeletionResponse()
+				}
+				err = rep().As(user.UserName).RestoreUser(user)
+			}
+		}
+		if err != nil {
+			logger.Error("查询用户错误", err)
+			return data.NewErrorResponse(20002, "查询用户失败")
+		}
+	} else if user, err = createOidcUser(providerName, claims); err != nil {
+		return data.NewErrorResponse(20033, err.Error())
+	}
+	return completeLogin(user, state.Device, client)
+}
+
+// linkIdentity 将外部身份绑定到当前用户
+func linkIdentity(username string, identity *model.Identity, providerName string, claims *oidc.Claims) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	if identity != nil {
+		if identity.UserID == user.ID {
+			return data.NewSuccessResponse("已绑定")
+		}
+		return data.NewErrorResponse(20034, "该外部账号已绑定其他用户")
+	}
+
+	err = rep().As(username).Create(&model.Identity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}).Error
+	if err != nil {
+		logger.Error("绑定外部身份错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "绑定失败")
+	}
+	return data.NewSuccessResponse("绑定成功")
+}
+
+// createOidcUser 首次使用外部身份登录时创建用户
+// 邮箱已被本地账号使用时不自动合并，需用户登录后手动绑定，避免账号被接管
+func createOidcUser(providerName string, claims *oidc.Claims) (*model.User, error) {
+	email := ""
+	if claims.EmailVerified && claims.Email != "" {
+		count := int64(0)
+		rep().Unscoped().Model(&model.User{}).Where("email = ?", claims.Email).Count(&count)
+		if count > 0 {
+			return nil, errors.New("邮箱已被其他账号使用，请登录该账号后绑定")
+		}
+		email = claims.Email
+	}
+
+	username := fmt.Sprintf("%s_%s", providerName, randomHex(6))
+	if len(username) > 30 {
+		username = username[len(username)-30:]
+	}
+	nickname := claims.Name
+	if nickname == "" || len([]rune(nickname)) > 24 {
+		nickname = username
+	}
+	count := int64(0)
+	rep().Unscoped().Model(&model.User{}).Where("nickname = ?", nickname).Count(&count)
+	if count > 0 {
+		nickname = fmt.Sprintf("%s_%s", nickname, randomHex(2))
+	}
+	avatar := claims.Picture
+	if len(avatar) > 1000 {
+		avatar = ""
+	}
+
+	user := &model.User{
+		UserName: username,
+		Email:    email,
+		Nickname: nickname,
+		Avatar:   avatar,
+		Status:   model.Active,
+	}
+	err := rep().As(username).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.Identity{
+			UserID:   user.ID,
+			Provider: providerName,
+			Subject:  claims.Subject,
+			Email:    claims.Email,
+		}).Error
+	})
+	if err != nil {
+		logger.Error("创建外部登录用户错误", err)
+		return nil, errors.New("注册失败")
+	}
+	return user, nil
+}
+
+// ListIdentities 列出当前用户绑定的外部身份
+func ListIdentities(username string) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	identities, err := rep().GetIdentities(user.ID)
+	if err != nil {
+		logger.Error("查询外部身份错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "查询外部身份失败")
+	}
+	return data.NewDataResponse(data.BuildIdentities(identities))
+}
+
+// DeleteIdentity 解除外部身份绑定，未设置密码时不能解除最后一个外部身份
+func DeleteIdentity(ctx context.Context, username string, id uint) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	identities, err := rep().GetIdentities(user.ID)
+	if err != nil {
+		logger.Error("查询外部身份错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "查询外部身份失败")
+	}
+
+	found := false
+	for _, identity := range identities {
+		if identity.ID == id {
+			found = true
+		}
+	}
+	if !found {
+		return data.NewErrorResponse(20035, "外部身份不存在")
+	}
+	if user.PasswordDigest == "" && len(identities) == 1 {
+		return data.NewErrorResponse(20036, "请先设置密码后再解除最后一个外部身份")
+	}
+
+	if err = rep().WithCtx(ctx).Delete(&model.Identity{}, id).Error; err != nil {
+		logger.Error("解除外部身份错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "解除绑定失败")
+	}
+	return data.NewSuccessResponse("解除绑定成功")
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"singo/conf"
+	"singo/data"
+	"singo/model"
+	"singo/oidc"
+	"singo/oidc/oidctest"
+	"singo/req"
+	"singo/testutil"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var testClient = &req.Client{IP: "127.0.0.1", UserAgent: "go-test"}
+
+// userCtx 模拟认证中间件写入操作人的请求上下文
+func userCtx(username string) context.Context {
+	return model.WithOperator(context.Background(), username)
+}
+
+func setupOidc(t *testing.T) *oidctest.Issuer {
+	testutil.Setup(t)
+	issuer := oidctest.NewIssuer("client-1", "secret-1")
+	t.Cleanup(issuer.Close)
+	oidc.RegisterProvider(conf.OidcProviderConfig{
+		Name:         "mock",
+		Issuer:       issuer.URL,
+		ClientID:     "client-1",
+		ClientSecret: "secret-1",
+		RedirectURL:  "http://localhost/callback",
+	})
+	return issuer
+}
+
+// oidcLogin 走完跳转及回调流程，claims根据本次登录的nonce生成ID Token声明
+func oidcLogin(t *testing.T, issuer *oidctest.Issuer, linkUser string, claims func(nonce string) jwt.MapClaims) *data.Response {
+	t.Helper()
+	return oidcLoginWith(t, issuer, linkUser, &OidcLoginReq{Device: "test"}, claims)
+}
+
+func oidcLoginWith(t *testing.T, issuer *oidctest.Issuer, linkUser string, param *OidcLoginReq, claims func(nonce string) jwt.MapClaims) *data.Response {
+	t.Helper()
+	resp := OidcAuthURL("mock", linkUser, param)
+	if !resp.Success {
+		t.Fatalf("OidcAuthURL() = %+v", resp)
+	}
+	authURL, err := url.Parse(resp.Data.(*data.OidcRedirectReq).AuthURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := authURL.Query()
+	issuer.SetIDToken(issuer.Sign(claims(query.Get("nonce"))))
+	return OidcCallback("mock", &OidcCallbackReq{Code: "code-1", State: query.Get("state")}, testClient)
+}
+
+func createTestUser(t *testing.T, username, email, password string) *model.User {
+	t.Helper()
+	user := &model.User{UserName: username, Email: email, Nickname: username, Status: model.Active}
+	if password != "" {
+		if err := user.SetPassword(password); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rep().Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func countUsers(t *testing.T) int64 {
+	t.Helper()
+	var count int64
+	if err := rep().Model(&model.User{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestOidcAutoCreate(t *testing.T) {
+	issuer := setupOidc(t)
+	claims := func(nonce string) jwt.MapClaims {
+		c := issuer.Claims("sub-1", nonce)
+		c["email"] = "alice@example.com"
+		c["email_verified"] = true
+		c["name"] = "Alice"
+		return c
+	}
+
+	resp := oidcLogin(t, issuer, "", claims)
+	if !resp.Success {
+		t.Fatalf("首次登录 = %+v", resp)
+	}
+	user := resp.Data.(*data.UserReq)
+	if user.Token == "" || user.Email != "alice@example.com" || user.Nickname != "Alice" || user.HasPassword {
+		t.Errorf("首次登录用户 = %+v", user)
+	}
+	identity, err := rep().GetIdentity("mock", "sub-1")
+	if err != nil || identity.UserID != user.ID {
+		t.Fatalf("外部身份未绑定 %v %+v", err, identity)
+	}
+
+	// 再次登录使用已创建的用户
+	resp = oidcLogin(t, issuer, "", claims)
+	if !resp.Success || resp.Data.(*data.UserReq).ID != user.ID {
+		t.Errorf("再次登录 = %+v", resp)
+	}
+	if count := countUsers(t); count != 1 {
+		t.Errorf("用户数 = %d, want 1", count)
+	}
+}
+
+func TestOidcUnverifiedEmail(t *testing.T) {
+	issuer := setupOidc(t)
+	createTestUser(t, "alice01", "alice@example.com", "")
+
+	// 未验证的邮箱不写入用户，也不与已有账号冲突
+	resp := oidcLogin(t, issuer, "", func(nonce string) jwt.MapClaims {
+		c := issuer.Claims("sub-1", nonce)
+		c["email"] = "alice@example.com"
+		c["email_verified"] = false
+		return c
+	})
+	if !resp.Success {
+		t.Fatalf("登录 = %+v", resp)
+	}
+	if email := resp.Data.(*data.UserReq).Email; email != "" {
+		t.Errorf("未验证邮箱被写入用户 %s", email)
+	}
+}
+
+func TestOidcEmailConflict(t *testing.T) {
+	issuer := setupOidc(t)
+	createTestUser(t, "alice01", "alice@example.com", "")
+
+	// 已验证的邮箱已被本地账号使用时不自动合并
+	resp := oidcLogin(t, issuer, "", func(nonce string) jwt.MapClaims {
+		c := issuer.Claims("sub-1", nonce)
+		c["email"] = "alice@example.com"
+		c["email_verified"] = true
+		return c
+	})
+	if resp.Success || resp.ErrCode != 20033 {
+		t.Errorf("登录 = %+v, want 20033", resp)
+	}
+	if count := countUsers(t); count != 1 {
+		t.Errorf("用户数 = %d, want 1", count)
+	}
+}
+
+func TestOidcLink(t *testing.T) {
+	issuer := setupOidc(t)
+	alice := createTestUser(t, "alice01", "alice@example.com", "")
+	createTestUser(t, "bob0001", "bob@example.com", "")
+	claims := func(nonce string) jwt.MapClaims {
+		return issuer.Claims("sub-1", nonce)
+	}
+
+	resp := oidcLogin(t, issuer, "alice01", claims)
+	if !resp.Success {
+		t.Fatalf("绑定 = %+v", resp)
+	}
+	identity, err := rep().GetIdentity("mock", "sub-1")
+	if err != nil || identity.UserID != alice.ID || identity.CreatedBy != "alice01" {
+		t.Fatalf("外部身份 = %+v, %v", identity, err)
+	}
+
+	// 重复绑定
+	if resp = oidcLogin(t, issuer, "alice01", claims); !resp.Success {
+		t.Errorf("重复绑定 = %+v", resp)
+	}
+	// 已绑定其他用户
+	if resp = oidcLogin(t, issuer, "bob0001", claims); resp.ErrCode != 20034 {
+		t.Errorf("绑定其他用户的身份 = %+v, want 20034", resp)
+	}
+
+	// 绑定后使用外部身份登录到原账号
+	resp = oidcLogin(t, issuer, "", claims)
+	if !resp.Success || resp.Data.(*data.UserReq).UserName != "alice01" {
+		t.Errorf("绑定后登录 = %+v", resp)
+	}
+}
+
+func TestOidcInvalidCallback(t *testing.T) {
+	issuer := setupOidc(t)
+
+	resp := OidcCallback("mock", &OidcCallbackReq{Code: "code-1", State: "unknown"}, testClient)
+	if resp.ErrCode != 20032 {
+		t.Errorf("未知状态 = %+v, want 20032", resp)
+	}
+
+	// nonce不匹配时拒绝登录
+	resp = oidcLogin(t, issuer, "", func(nonce string) jwt.MapClaims {
+		return issuer.Claims("sub-1", "other-nonce")
+	})
+	if resp.ErrCode != 20031 {
+		t.Errorf("nonce不匹配 = %+v, want 20031", resp)
+	}
+	if count := countUsers(t); count != 0 {
+		t.Errorf("用户数 = %d, want 0", count)
+	}
+}
+
+func TestOidcUserSetPassword(t *testing.T) {
+	issuer := setupOidc(t)
+	resp := oidcLogin(t, issuer, "", func(nonce string) jwt.MapClaims {
+		return issuer.Claims("sub-1", nonce)
+	})
+	if !resp.Success {
+		t.Fatalf("登录 = %+v", resp)
+	}
+	username := resp.Data.(*data.UserReq).UserName
+	identities, err := rep().GetIdentities(resp.Data.(*data.UserReq).ID)
+	if err != nil || len(identities) != 1 {
+		t.Fatalf("外部身份 = %v, %v", identities, err)
+	}
+
+	// 未设置密码时不能解除最后一个外部身份
+	if resp = DeleteIdentity(userCtx(username), username, identities[0].ID); resp.ErrCode != 20036 {
+		t.Errorf("解除绑定 = %+v, want 20036", resp)
+	}
+
+	if resp = SetPassword(userCtx(username), username, &PasswordSetReq{Password: "Gz8#kq2Lmv", PasswordConfirm: "Gz8#kq2Lmv"}); !resp.Success {
+		t.Fatalf("设置密码 = %+v", resp)
+	}
+	if resp = SetPassword(userCtx(username), username, &PasswordSetReq{Password: "Gz8#kq2Lmw", PasswordConfirm: "Gz8#kq2Lmw"}); resp.ErrCode != 20047 {
+		t.Errorf("重复设置密码 = %+v, want 20047", resp)
+	}
+	user, _ := rep().GetUser(username)
+	if !user.CheckPassword("Gz8#kq2Lmv") {
+		t.Error("设置的密码校验失败")
+	}
+
+	if resp = DeleteIdentity(userCtx(username), username, identities[0].ID); !resp.Success {
+		t.Errorf("设置密码后解除绑定 = %+v", resp)
+	}
+}
+
+func TestOidcRestore(t *testing.T) {
+	issuer := setupOidc(t)
+	claims := func(nonce string) jwt.MapClaims {
+		return issuer.Claims("sub-1", nonce)
+	}
+	resp := oidcLogin(t, issuer, "", claims)
+	if !resp.Success {
+		t.Fatalf("登录 = %+v", resp)
+	}
+	user := resp.Data.(*data.UserReq)
+	if resp = DeleteAccount(userCtx(user.UserName), user.UserName, &UserDeleteReq{}); !resp.Success {
+		t.Fatalf("DeleteAccount() = %+v", resp)
+	}
+
+	if resp = oidcLogin(t, issuer, "", claims); resp.ErrCode != 20048 {
+		t.Errorf("注销后登录 = %+v, want 20048", resp)
+	}
+	resp = oidcLoginWith(t, issuer, "", &OidcLoginReq{Restore: true}, claims)
+	if !resp.Success || resp.Data.(*data.UserReq).ID != user.ID {
+		t.Fatalf("恢复登录 = %+v", resp)
+	}
+	if resp = oidcLogin(t, issuer, "", claims); !resp.Success {
+		t.Errorf("恢复后登录 = %+v", resp)
+	}
+}
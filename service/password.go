@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"singo/cache"
+	"singo/conf"
+	"singo/data"
+	"singo/logger"
+	"singo/mailer"
+	"singo/model"
+	"singo/policy"
+	"singo/util"
+)
+
+// checkPasswordPolicy 检查密码策略及历史密码，不符合时在Data中返回全部原因
+func checkPasswordPolicy(user *model.User, password string) *data.Response {
+	reasons := policy.CheckPassword(password, user.UserName, user.Nickname)
+	if history := conf.GetConfig().Password.History; history > 0 && passwordReused(user, password, history) {
+		reasons = append(reasons, policy.Reason{
+			Code:    policy.ReasonReused,
+			Message: fmt.Sprintf("不能与最近%d次使用过的密码相同", history),
+		})
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+	resp := data.NewErrorResponse(20037, "密码不符合安全要求")
+	resp.Data = reasons
+	return resp
+}
+
+// passwordReused 判断密码是否与当前密码或历史密码相同，当前密码计入历史次数
+func passwordReused(user *model.User, password string, history int) bool {
+	if user.ID == 0 || user.PasswordDigest == "" {
+		return false
+	}
+	if user.CheckPassword(password) {
+		return true
+	}
+	if history <= 1 {
+		return false
+	}
+	array, err := rep().GetPasswordHistory(user.ID, history-1)
+	if err != nil {
+		logger.Error("查询历史密码错误", err)
+		return false
+	}
+	for _, item := range array {
+		if (&model.User{PasswordDigest: item.PasswordDigest}).CheckPassword(password) {
+			return true
+		}
+	}
+	return false
+}
+
+// updatePassword 修改密码并撤销用户全部会话，调用前需先检查密码策略
+func updatePassword(ctx context.Context, user *model.User, password string) *data.Response {
+	previous := user.PasswordDigest
+	if err := user.SetPassword(password); err != nil {
+		return data.NewErrorResponse(data.CodeEncryptError, "密码加密失败")
+	}
+	if err := rep().WithCtx(ctx).Model(user).Update("password_digest", user.PasswordDigest).Error; err != nil {
+		logger.Error("修改密码错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "修改密码失败")
+	}
+	if history := conf.GetConfig().Password.History; history > 1 && previous != "" {
+		if err := rep().WithCtx(ctx).AddPasswordHistory(user.ID, previous, history-1); err != nil {
+			logger.Error("记录历史密码错误", err)
+		}
+	}
+	// 修改密码后已颁发的会话及OAuth2 Token全部失效
+	revokeUserTokens(user.UserName)
+	return nil
+}
+
+// @Description 忘记密码请求
+type PasswordForgotReq struct {
+	// 邮箱
+	Email string `form:"email" json:"email" binding:"required,email,max=100"`
+}
+
+// ForgotPassword 发送重置密码邮件，无论邮箱是否存在都返回相同信息
+func ForgotPassword(service *PasswordForgotReq) *data.Response {
+	resp := data.NewSuccessResponse("如邮箱已注册，重置密码邮件已发送")
+
+	user, err := rep().GetUserByEmail(service.Email)
+	if err != nil {
+		return resp
+	}
+
+	cfg := conf.GetConfig()
+	ok, err := redis().Cooldown("password_forgot:"+user.UserName, cfg.Mail.ResendInterval)
+	if err != nil {
+		logger.Error("查询发送频率错误", err)
+		return data.NewErrorResponse(20016, "发送失败")
+	}
+	// 发送过于频繁时同样返回通用信息，避免通过响应差异判断邮箱是否已注册
+	if !ok {
+		return resp
+	}
+
+	token, err := util.RandomToken(32)
+	if err != nil {
+		logger.Error("生成重置Token错误", err)
+		return data.NewErrorResponse(20016, "发送失败")
+	}
+	if err = redis().SetResetToken(util.HashToken(token), user.UserName, cfg.Mail.ResetExpire); err != nil {
+		logger.Error("存储重置Token错误", err)
+		return data.NewErrorResponse(20016, "发送失败")
+	}
+
+	body := fmt.Sprintf("%s，您好：\n\n请在%s内打开以下链接重置密码，链接仅能使用一次：\n%s?token=%s\n\n如非本人操作请忽略本邮件。",
+		user.Nickname, cfg.Mail.ResetExpire, cfg.Mail.ResetURL, token)
+	if err = mailer.GetMailer().Send(user.Email, "重置您的密码", body); err != nil {
+		logger.Error("发送重置密码邮件错误", err)
+		return data.NewErrorResponse(20016, "发送失败")
+	}
+	return resp
+}
+
+// @Description 重置密码请求
+type PasswordResetReq struct {
+	// 重置Token
+	Token string `form:"token" json:"token" binding:"required"`
+	// 新密码
+	Password string `form:"password" json:"password" binding:"required,min=8,max=40"`
+	// 确认新密码
+	PasswordConfirm string `form:"password_confirm" json:"password_confirm" binding:"required,eqfield=Password"`
+}
+
+// ResetPassword 使用重置Token设置新密码，密码不符合要求时Token仍可继续使用
+func ResetPassword(service *PasswordResetReq) *data.Response {
+	hash := util.HashToken(service.Token)
+	username, err := redis().GetResetToken(hash)
+	if err != nil {
+		if err != cache.Nil {
+			logger.Error("查询重置Token错误", err)
+		}
+		return data.NewErrorResponse(20018, "重置链接无效或已过期")
+	}
+
+	user, err := rep().GetUser(username)
+	if err != nil {
+		return data.NewErrorResponse(20018, "重置链接无效或已过期")
+	}
+	if resp := checkPasswordPolicy(user, service.Password); resp != nil {
+		return resp
+	}
+
+	// 取出并删除Token，保证并发请求时只有一次生效
+	if taken, err := redis().TakeResetToken(hash); err != nil || taken != username {
+		if err != nil && err != cache.Nil {
+			logger.Error("查询重置Token错误", err)
+		}
+		return data.NewErrorResponse(20018, "重置链接无效或已过期")
+	}
+
+	// 通过重置链接修改密码时未登录，操作人为用户本人
+	if resp := updatePassword(model.WithOperator(context.Background(), user.UserName), user, service.Password); resp != nil {
+		return resp
+	}
+	if err = redis().ClearLoginFail(cache.LoginUserSubject(username)); err != nil {
+		logger.Error("清除登录失败次数错误", err)
+	}
+	return data.NewSuccessResponse("密码已重置，请重新登录")
+}
+
+// @Description 修改密码请求
+type PasswordChangeReq struct {
+	// 原密码
+	OldPassword string `form:"old_password" json:"old_password" binding:"required"`
+	// 新密码
+	Password string `form:"password" json:"password" binding:"required,min=8,max=40"`
+	// 确认新密码
+	PasswordConfirm string `form:"password_confirm" json:"password_confirm" binding:"required,eqfield=Password"`
+}
+
+// ChangePassword 校验原密码后修改密码
+func ChangePassword(ctx context.Context, username string, service *PasswordChangeReq) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	if !user.CheckPassword(service.OldPassword) {
+		return data.NewErrorResponse(20019, "原密码错误")
+	}
+	if resp := checkPasswordPolicy(user, service.Password); resp != nil {
+		return resp
+	}
+
+	if resp := updatePassword(ctx, user, service.Password); resp != nil {
+		return resp
+	}
+	return data.NewSuccessResponse("密码已修改，请重新登录")
+}
+
+// @Description 设置密码请求
+type PasswordSetReq struct {
+	// 新密码
+	Password string `form:"password" json:"password" binding:"required,min=8,max=40"`
+	// 确认新密码
+	PasswordConfirm string `form:"password_confirm" json:"password_confirm" binding:"required,eqfield=Password"`
+}
+
+// SetPassword 为未设置密码的用户设置密码，外部身份或短信验证码注册的用户默认没有密码
+func SetPassword(ctx context.Context, username string, service *PasswordSetReq) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	if user.PasswordDigest != "" {
+		return data.NewErrorResponse(20047, "已设置密码，请使用修改密码")
+	}
+	if resp := checkPasswordPolicy(user, service.Password); resp != nil {
+		return resp
+	}
+
+	if resp := updatePassword(ctx, user, service.Password); resp != nil {
+		return resp
+	}
+	return data.NewSuccessResponse("密码已设置，请重新登录")
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"reflect"
+	"singo/hasher"
+	"singo/testutil"
+	"strings"
+	"testing"
+)
+
+func TestForgotPasswordThrottleIndistinguishable(t *testing.T) {
+	testutil.Setup(t)
+	createTestUser(t, "alice01", "alice@example.com", "Gz8#kq2Lmv")
+
+	unknown := ForgotPassword(&PasswordForgotReq{Email: "nobody@example.com"})
+	for i := 0; i < 2; i++ {
+		// 发送过于频繁时的响应与未注册邮箱相同
+		if resp := ForgotPassword(&PasswordForgotReq{Email: "alice@example.com"}); !reflect.DeepEqual(resp, unknown) {
+			t.Errorf("第%d次忘记密码 = %+v, 期望 %+v", i+1, resp, unknown)
+		}
+	}
+}
+
+func TestRehashOnLogin(t *testing.T) {
+	testutil.Setup(t)
+	previous := hasher.GetHasher()
+	t.Cleanup(func() { hasher.SetHasher(previous) })
+
+	hasher.SetHasher(&hasher.BcryptHasher{Cost: 4})
+	user := createTestUser(t, "alice01", "alice@example.com", "Gz8#kq2Lmv")
+	if !strings.HasPrefix(user.PasswordDigest, "$2a$04$") {
+		t.Fatalf("初始摘要 = %s", user.PasswordDigest)
+	}
+
+	// 修改算法后，旧摘要仍可登录并在登录成功后升级
+	hasher.SetHasher(&hasher.Argon2Hasher{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, testClient); !resp.Success {
+		t.Fatalf("旧摘要登录 = %+v", resp)
+	}
+	user, err := rep().GetUser("alice01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(user.PasswordDigest, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("登录后摘要 = %s, 未升级为argon2id", user.PasswordDigest)
+	}
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmv"}, testClient); !resp.Success {
+		t.Errorf("升级后登录 = %+v", resp)
+	}
+
+	// 登录失败时不升级摘要
+	hasher.SetHasher(&hasher.BcryptHasher{Cost: 4})
+	if resp := Login(&UserLoginReq{UserName: "alice01", Password: "Gz8#kq2Lmw"}, testClient); resp.Success {
+		t.Fatal("错误密码登录成功")
+	}
+	if user, _ = rep().GetUser("alice01"); !strings.HasPrefix(user.PasswordDigest, "$argon2id$") {
+		t.Errorf("登录失败后摘要 = %s", user.PasswordDigest)
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"singo/conf"
+	"singo/data"
+	"singo/logger"
+	"singo/storage"
+	"singo/util"
+	"strings"
+)
+
+// @Description 修改个人资料请求，未填写的字段保持不变
+type UserUpdateReq struct {
+	// 昵称
+	Nickname string `form:"nickname" json:"nickname" binding:"omitempty,min=2,max=30"`
+}
+
+// UpdateProfile 修改个人资料
+func UpdateProfile(ctx context.Context, username string, service *UserUpdateReq) *data.Response {
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+
+	updates := map[string]interface{}{}
+	if service.Nickname != "" && service.Nickname != user.Nickname {
+		if nicknameTaken(service.Nickname, user.ID) {
+			return data.NewErrorResponse(40001, "昵称被占用")
+		}
+		updates["nickname"] = service.Nickname
+	}
+	if len(updates) > 0 {
+		if err = rep().WithCtx(ctx).Model(user).Updates(updates).Error; err != nil {
+			logger.Error("修改个人资料错误", err)
+			return data.NewErrorResponse(data.CodeDBError, "修改个人资料失败")
+		}
+	}
+	return data.NewDataResponse(data.BuildUser(user))
+}
+
+// avatarTypes 允许上传的头像格式及对应的解码器
+var avatarTypes = map[string]func(io.Reader) (image.Image, error){
+	"image/jpeg": jpeg.Decode,
+	"image/png":  png.Decode,
+	"image/gif":  gif.Decode,
+}
+
+// avatarPrefix 用户头像对象的Key前缀
+func avatarPrefix(userID uint) string {
+	return fmt.Sprintf("avatars/%d/", userID)
+}
+
+// avatarKey 从头像地址中取出对象Key，外部身份提供的头像地址返回空字符串
+func avatarKey(userID uint, avatar string) string {
+	idx := strings.Index(avatar, avatarPrefix(userID))
+	if idx < 0 {
+		return ""
+	}
+	return avatar[idx:]
+}
+
+// deleteOldAvatar 删除之前上传的头像的全部尺寸，外部身份提供的头像地址不处理
+func deleteOldAvatar(userID uint, avatar string) {
+	// 头像Key为 avatars/<id>/<随机串>_<尺寸>.png，按随机串删除该次上传的全部尺寸
+	key := avatarKey(userID, avatar)
+	sep := strings.LastIndex(key, "_")
+	if sep <= len(avatarPrefix(userID)) {
+		return
+	}
+	if err := storage.GetStorage().DeletePrefix(key[:sep+1]); err != nil {
+		logger.Error("删除旧头像错误", err)
+	}
+}
+
+// UploadAvatar 上传头像，校验格式及大小后生成各尺寸缩略图，第一个尺寸作为头像地址
+func UploadAvatar(ctx context.Context, username string, file *multipart.FileHeader) *data.Response {
+	cfg := conf.GetConfig().Avatar
+	if file.Size > cfg.MaxSize {
+		return data.NewErrorResponse(20044, fmt.Sprintf("头像不能超过%dKB", cfg.MaxSize>>10))
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		logger.Error("读取上传文件错误", err)
+		return data.NewErrorResponse(20045, "上传头像失败")
+	}
+	defer f.Close()
+	content, err := io.ReadAll(io.LimitReader(f, cfg.MaxSize+1))
+	if err != nil {
+		logger.Error("读取上传文件错误", err)
+		return data.NewErrorResponse(20045, "上传头像失败")
+	}
+	if int64(len(content)) > cfg.MaxSize {
+		return data.NewErrorResponse(20044, fmt.Sprintf("头像不能超过%dKB", cfg.MaxSize>>10))
+	}
+
+	// 按文件内容判断格式，不信任客户端提交的Content-Type
+	decode, ok := avatarTypes[http.DetectContentType(content)]
+	if !ok {
+		return data.NewErrorResponse(20044, "头像仅支持JPEG、PNG、GIF格式")
+	}
+	// 解码前先检查尺寸，避免超大图片占用内存
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil || config.Width == 0 || config.Height == 0 {
+		return data.NewErrorResponse(20044, "图片无法识别")
+	}
+	if config.Width > cfg.MaxDimension || config.Height > cfg.MaxDimension {
+		return data.NewErrorResponse(20044, fmt.Sprintf("图片尺寸不能超过%dx%d", cfg.MaxDimension, cfg.MaxDimension))
+	}
+	img, err := decode(bytes.NewReader(content))
+	if err != nil {
+		return data.NewErrorResponse(20044, "图片无法识别")
+	}
+
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+
+	resp := &data.AvatarReq{Thumbnails: map[int]string{}}
+	prefix := avatarPrefix(user.ID) + randomHex(8)
+	for _, size := range cfg.Sizes {
+		var buf bytes.Buffer
+		if err = png.Encode(&buf, util.Thumbnail(img, size)); err != nil {
+			logger.Error("生成缩略图错误", err)
+			return data.NewErrorResponse(20045, "上传头像失败")
+		}
+		url, err := storage.GetStorage().Put(fmt.Sprintf("%s_%d.png", prefix, size), buf.Bytes(), "image/png")
+		if err != nil {
+			logger.Error("保存头像错误", err)
+			return data.NewErrorResponse(20045, "上传头像失败")
+		}
+		resp.Thumbnails[size] = url
+		if resp.Avatar == "" {
+			resp.Avatar = url
+		}
+	}
+
+	previous := user.Avatar
+	if err = rep().WithCtx(ctx).Model(user).Update("avatar", resp.Avatar).Error; err != nil {
+		logger.Error("修改头像错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "修改头像失败")
+	}
+	deleteOldAvatar(user.ID, previous)
+	return data.NewDataResponse(resp)
+}
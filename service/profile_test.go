@@ -0,0 +1,132 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"singo/conf"
+	"singo/data"
+	"singo/storage"
+	"singo/testutil"
+	"testing"
+)
+
+// setupStorage 使用临时目录作为对象存储，返回存储目录
+func setupStorage(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	storage.SetStorage(storage.NewLocalStorage(dir, "/uploads"))
+	return dir
+}
+
+// multipartFile 构造上传文件
+func multipartFile(t *testing.T, name string, content []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("avatar", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write(content)
+	_ = writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = form.RemoveAll() })
+	return form.File["avatar"][0]
+}
+
+func pngImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// countFiles 统计目录下的文件数
+func countFiles(t *testing.T, dir string) int {
+	t.Helper()
+	count := 0
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			count++
+		}
+		return nil
+	})
+	return count
+}
+
+func TestUploadAvatarReplacesOld(t *testing.T) {
+	testutil.Setup(t)
+	dir := setupStorage(t)
+	user := createTestUser(t, "alice01", "alice@example.com", "")
+	sizes := len(conf.GetConfig().Avatar.Sizes)
+
+	resp := UploadAvatar(userCtx("alice01"), "alice01", multipartFile(t, "a.png", pngImage(t, 300, 300)))
+	if !resp.Success {
+		t.Fatalf("UploadAvatar() = %+v", resp)
+	}
+	first := resp.Data.(*data.AvatarReq)
+	if len(first.Thumbnails) != sizes || countFiles(t, dir) != sizes {
+		t.Fatalf("缩略图 = %v, 文件数 = %d", first.Thumbnails, countFiles(t, dir))
+	}
+
+	resp = UploadAvatar(userCtx("alice01"), "alice01", multipartFile(t, "b.png", pngImage(t, 300, 300)))
+	if !resp.Success {
+		t.Fatalf("UploadAvatar() = %+v", resp)
+	}
+	// 只保留新头像的各尺寸
+	if count := countFiles(t, dir); count != sizes {
+		t.Errorf("替换头像后文件数 = %d, want %d", count, sizes)
+	}
+	latest, _ := rep().GetUser(user.UserName)
+	if latest.Avatar != resp.Data.(*data.AvatarReq).Avatar {
+		t.Errorf("头像地址 = %s", latest.Avatar)
+	}
+}
+
+func TestUploadAvatarRejects(t *testing.T) {
+	testutil.Setup(t)
+	dir := setupStorage(t)
+	createTestUser(t, "alice01", "alice@example.com", "")
+	cfg := conf.GetConfig().Avatar
+
+	oversized := multipartFile(t, "big.png", make([]byte, cfg.MaxSize+1))
+	// 客户端声明的大小不可信，按实际读取的内容判断
+	lying := multipartFile(t, "big.png", make([]byte, cfg.MaxSize+1))
+	lying.Size = 1
+
+	tests := []struct {
+		name string
+		file *multipart.FileHeader
+	}{
+		{name: "文件过大", file: oversized},
+		{name: "声明大小与内容不符", file: lying},
+		{name: "非图片", file: multipartFile(t, "a.png", []byte("hello world"))},
+		{name: "不支持的格式", file: multipartFile(t, "a.bmp", append([]byte("BM"), make([]byte, 64)...))},
+		{name: "损坏的图片", file: multipartFile(t, "a.png", pngImage(t, 10, 10)[:40])},
+		{name: "宽度超限", file: multipartFile(t, "a.png", pngImage(t, cfg.MaxDimension+1, 1))},
+		{name: "高度超限", file: multipartFile(t, "a.png", pngImage(t, 1, cfg.MaxDimension+1))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := UploadAvatar(userCtx("alice01"), "alice01", tt.file); resp.ErrCode != 20044 {
+				t.Errorf("UploadAvatar() = %+v, want 20044", resp)
+			}
+		})
+	}
+	if count := countFiles(t, dir); count != 0 {
+		t.Errorf("被拒绝的头像写入了%d个文件", count)
+	}
+	if user, _ := rep().GetUser("alice01"); user.Avatar != "" {
+		t.Errorf("被拒绝的头像写入了用户 %s", user.Avatar)
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"singo/data"
+	"singo/logger"
+	"singo/model"
+)
+
+// @Description 角色创建及修改请求
+type RoleReq struct {
+	// 角色名
+	Name string `form:"name" json:"name" binding:"required,min=2,max=50"`
+	// 描述
+	Description string `form:"description" json:"description" binding:"max=200"`
+	// 权限编码
+	Permissions []string `form:"permissions" json:"permissions"`
+}
+
+// permissions 校验并获取请求中的权限
+func (service *RoleReq) permissions() ([]*model.Permission, *data.Response) {
+	if len(service.Permissions) == 0 {
+		return nil, nil
+	}
+	perms, err := rep().GetPermissionsByCodes(service.Permissions)
+	if err != nil {
+		logger.Error("查询权限错误", err)
+		return nil, data.NewErrorResponse(data.CodeDBError, "查询权限失败")
+	}
+	if len(perms) != len(service.Permissions) {
+		return nil, data.ParamErr("权限不存在")
+	}
+	return perms, nil
+}
+
+// ListRoles 获取全部角色
+func ListRoles() *data.Response {
+	roles, err := rep().GetRoles()
+	if err != nil {
+		logger.Error("查询角色错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "查询角色失败")
+	}
+	return data.NewDataResponse(data.BuildRoles(roles))
+}
+
+// ListPermissions 获取全部权限
+func ListPermissions() *data.Response {
+	perms, err := rep().GetPermissions()
+	if err != nil {
+		logger.Error("查询权限错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "查询权限失败")
+	}
+	return data.NewDataResponse(data.BuildPermissions(perms))
+}
+
+// CreateRole 创建角色
+func CreateRole(ctx context.Context, service *RoleReq) *data.Response {
+	count := int64(0)
+	rep().Model(&model.Role{}).Where("name = ?", service.Name).Count(&count)
+	if count > 0 {
+		return data.NewErrorResponse(30001, "角色名已存在")
+	}
+
+	perms, resp := service.permissions()
+	if resp != nil {
+		return resp
+	}
+
+	role := model.Role{
+		Name:        service.Name,
+		Description: service.Description,
+		Permissions: perms,
+	}
+	if err := rep().WithCtx(ctx).Create(&role).Error; err != nil {
+		logger.Error("创建角色错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "创建角色失败")
+	}
+	return data.NewDataResponse(data.BuildRole(&role))
+}
+
+// UpdateRole 修改角色描述及权限
+func UpdateRole(ctx context.Context, id uint, service *RoleReq) *data.Response {
+	role, err := rep().GetRole(id)
+	if err != nil {
+		return data.NewErrorResponse(30002, "角色不存在")
+	}
+	if role.Name != service.Name {
+		return data.ParamErr("角色名不可修改")
+	}
+
+	perms, resp := service.permissions()
+	if resp != nil {
+		return resp
+	}
+
+	role.Description = service.Description
+	if err = rep().WithCtx(ctx).Save(role).Error; err != nil {
+		logger.Error("修改角色错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "修改角色失败")
+	}
+	if err = rep().Model(role).Association("Permissions").Replace(perms); err != nil {
+		logger.Error("修改角色权限错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "修改角色失败")
+	}
+	role.Permissions = perms
+	return data.NewDataResponse(data.BuildRole(role))
+}
+
+// DeleteRole 删除角色
+func DeleteRole(ctx context.Context, id uint) *data.Response {
+	role, err := rep().GetRole(id)
+	if err != nil {
+		return data.NewErrorResponse(30002, "角色不存在")
+	}
+	if role.Name == model.AdminRole {
+		return data.NewErrorResponse(30003, "内置角色不可删除")
+	}
+
+	if err = rep().WithCtx(ctx).Select("Permissions").Delete(role).Error; err != nil {
+		logger.Error("删除角色错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "删除角色失败")
+	}
+	if err = rep().Exec("DELETE FROM user_roles WHERE role_id = ?", role.ID).Error; err != nil {
+		logger.Error("删除角色分配错误", err)
+	}
+	return data.NewSuccessResponse("删除成功")
+}
+
+// @Description 用户角色分配请求
+type UserRolesReq struct {
+	// 用户名
+	UserName string `form:"user_name" json:"user_name" binding:"required,min=5,max=30"`
+	// 角色名
+	Roles []string `form:"roles" json:"roles"`
+}
+
+// SetUserRoles 设置用户角色，并撤销其全部会话使新角色立即生效
+func SetUserRoles(ctx context.Context, service *UserRolesReq) *data.Response {
+	user, err := rep().GetUser(service.UserName)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+
+	roles := make([]*model.Role, 0)
+	if len(service.Roles) > 0 {
+		if roles, err = rep().GetRolesByNames(service.Roles); err != nil {
+			logger.Error("查询角色错误", err)
+			return data.NewErrorResponse(data.CodeDBError, "查询角色失败")
+		}
+		if len(roles) != len(service.Roles) {
+			return data.NewErrorResponse(30002, "角色不存在")
+		}
+	}
+
+	if err = rep().WithCtx(ctx).Model(user).Association("Roles").Replace(roles); err != nil {
+		logger.Error("分配角色错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "分配角色失败")
+	}
+	if err = redis().DelUserSessions(user.UserName); err != nil {
+		logger.Error("删除会话错误", err)
+	}
+	return data.NewSuccessResponse("分配成功，用户需重新登录")
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"singo/cache"
+	"singo/data"
 	"singo/model"
+	"singo/req"
 )
 
 func rep() *model.MyDb {
@@ -12,3 +14,11 @@ func rep() *model.MyDb {
 func redis() *cache.MyRedis {
 	return cache.GetRedisClient()
 }
+
+// pageResponse 按分页方式构造列表响应，游标分页返回下一页游标，不返回总数
+func pageResponse(param *req.PageReq, total int64, next string, items interface{}) *data.Response {
+	if param.CursorMode() {
+		return data.NewCursorResponse(next, items)
+	}
+	return data.NewPageResponse(total, items)
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"singo/data"
+	"singo/logger"
+)
+
+// ListSessions 列出当前用户的全部登录设备
+func ListSessions(username, current string) *data.Response {
+	sessions, err := redis().ListSessions(username)
+	if err != nil {
+		logger.Error("查询会话错误", err)
+		return data.NewErrorResponse(20008, "查询会话失败")
+	}
+	return data.NewDataResponse(data.BuildSessions(sessions, current))
+}
+
+// DeleteSession 远程注销指定设备
+func DeleteSession(username, sid string) *data.Response {
+	session, err := redis().GetSession(sid)
+	if err != nil || session.UserName != username {
+		return data.NewErrorResponse(20009, "会话不存在")
+	}
+
+	if err = redis().DelSession(username, sid); err != nil {
+		logger.Error("删除会话错误", err)
+		return data.NewErrorResponse(20004, "注销失败")
+	}
+	return data.NewSuccessResponse("注销成功")
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"singo/cache"
+	"singo/conf"
+	"singo/data"
+	"singo/logger"
+	"singo/model"
+	"singo/req"
+	"singo/sms"
+	"singo/util"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// smsCodeLength 短信验证码位数
+const smsCodeLength = 6
+
+var phonePattern = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+// @Description 发送短信验证码请求
+type SmsSendReq struct {
+	// 手机号
+	Phone string `form:"phone" json:"phone" binding:"required"`
+}
+
+// SendSmsCode 发送登录验证码，按手机号及IP限制发送频率
+func SendSmsCode(service *SmsSendReq, client *req.Client) *data.Response {
+	if !phonePattern.MatchString(service.Phone) {
+		return data.NewErrorResponse(20042, "手机号格式错误")
+	}
+	cfg := conf.GetConfig().Sms
+
+	count, err := redis().IncrLimit("sms_ip:"+client.IP, time.Hour)
+	if err != nil {
+		logger.Error("查询发送频率错误", err)
+		return data.NewErrorResponse(20039, "发送失败")
+	}
+	if count > cfg.IPHourlyLimit {
+		return data.NewErrorResponse(20040, "发送过于频繁，请稍后再试")
+	}
+
+	ok, err := redis().Cooldown("sms_phone:"+service.Phone, cfg.SendInterval)
+	if err != nil {
+		logger.Error("查询发送频率错误", err)
+		return data.NewErrorResponse(20039, "发送失败")
+	}
+	if !ok {
+		return data.NewErrorResponse(20040, "发送过于频繁，请稍后再试")
+	}
+	count, err = redis().IncrLimit("sms_daily:"+service.Phone, 24*time.Hour)
+	if err != nil {
+		logger.Error("查询发送频率错误", err)
+		return data.NewErrorResponse(20039, "发送失败")
+	}
+	if count > cfg.PhoneDailyLimit {
+		return data.NewErrorResponse(20040, "今日发送次数已达上限")
+	}
+
+	code, err := util.RandomDigits(smsCodeLength)
+	if err != nil {
+		logger.Error("生成短信验证码错误", err)
+		return data.NewErrorResponse(20039, "发送失败")
+	}
+	if err = redis().SetSmsCode(service.Phone, util.HashToken(code), cfg.CodeExpire); err != nil {
+		logger.Error("存储短信验证码错误", err)
+		return data.NewErrorResponse(20039, "发送失败")
+	}
+
+	content := fmt.Sprintf("【%s】您的登录验证码为%s，%d分钟内有效，请勿泄露给他人。", cfg.Sign, code, int(cfg.CodeExpire.Minutes()))
+	if err = sms.GetSender().Send(service.Phone, content); err != nil {
+		logger.Error("发送短信错误", err)
+		return data.NewErrorResponse(20039, "发送失败")
+	}
+	return data.NewSuccessResponse("验证码已发送")
+}
+
+// @Description 短信验证码登录请求
+type SmsLoginReq struct {
+	// 手机号
+	Phone string `form:"phone" json:"phone" binding:"required"`
+	// 验证码
+	Code string `form:"code" json:"code" binding:"required,len=6,numeric"`
+	// 设备名称
+	Device string `form:"device" json:"device" binding:"max=50"`
+}
+
+// SmsLogin 短信验证码登录，手机号未注册时自动创建账号
+func SmsLogin(service *SmsLoginReq, client *req.Client) *data.Response {
+	if !phonePattern.MatchString(service.Phone) {
+		return data.NewErrorResponse(20042, "手机号格式错误")
+	}
+	if resp := checkSmsCode(service.Phone, service.Code); resp != nil {
+		return resp
+	}
+
+	user, err := rep().GetUserByPhone(service.Phone)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 手机号属于注销宽限期内的账号时不再创建新账号
+		if deleted, e := rep().GetPendingDeletionUser("phone = ?", service.Phone); e == nil {
+			recordLogin(deleted, deleted.UserName, service.Device, client, model.LoginPendingDeletion)
+			return pendingDeletionResponse()
+		}
+		user, err = createSmsUser(service.Phone)
+	}
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+	return completeLogin(user, service.Device, client)
+}
+
+// checkSmsCode 校验短信验证码，超过尝试次数或校验成功后验证码作废
+func checkSmsCode(phone, code string) *data.Response {
+	ok, err := redis().CheckSmsCode(phone, util.HashToken(code), conf.GetConfig().Sms.MaxAttempts)
+	if err != nil && err != cache.Nil {
+		logger.Error("校验短信验证码错误", err)
+	}
+	if !ok {
+		return data.NewErrorResponse(20041, "验证码错误或已过期")
+	}
+	return nil
+}
+
+// createSmsUser 为未注册的手机号创建账号，账号无密码，可之后通过设置密码接口(SetPassword)设置
+func createSmsUser(phone string) (*model.User, error) {
+	username := "sms_" + randomHex(8)
+	nickname := fmt.Sprintf("用户%s_%s", phone[len(phone)-4:], randomHex(2))
+	user := &model.User{
+		UserName: username,
+		Phone:    &phone,
+		Nickname: nickname,
+		Status:   model.Active,
+	}
+	if err := rep().As(username).Create(user).Error; err != nil {
+		// 并发注册同一手机号时唯一索引冲突，重新查询已创建的用户
+		if existing, e := rep().GetUserByPhone(phone); e == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
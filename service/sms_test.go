@@ -0,0 +1,102 @@
+package service
+
+import (
+	"singo/conf"
+	"singo/data"
+	"singo/testutil"
+	"singo/util"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// smsLogin 写入已知验证码后使用短信验证码登录
+func smsLogin(t *testing.T, phone string) *data.Response {
+	t.Helper()
+	if err := redis().SetSmsCode(phone, util.HashToken("123456"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	return SmsLogin(&SmsLoginReq{Phone: phone, Code: "123456"}, testClient)
+}
+
+func TestSmsUserSetPassword(t *testing.T) {
+	testutil.Setup(t)
+
+	resp := smsLogin(t, "13800138000")
+	if !resp.Success {
+		t.Fatalf("短信登录 = %+v", resp)
+	}
+	user := resp.Data.(*data.UserReq)
+	if user.HasPassword || user.Phone != "13800138000" {
+		t.Errorf("短信注册用户 = %+v", user)
+	}
+
+	// 无密码的用户可设置密码，之后可使用密码登录
+	if resp = SetPassword(userCtx(user.UserName), user.UserName, &PasswordSetReq{Password: "Gz8#kq2Lmv", PasswordConfirm: "Gz8#kq2Lmv"}); !resp.Success {
+		t.Fatalf("设置密码 = %+v", resp)
+	}
+	if resp = Login(&UserLoginReq{UserName: user.UserName, Password: "Gz8#kq2Lmv"}, testClient); !resp.Success {
+		t.Errorf("密码登录 = %+v", resp)
+	}
+}
+
+func TestSmsCodeSingleUse(t *testing.T) {
+	testutil.Setup(t)
+
+	const phone = "13800138001"
+	if err := redis().SetSmsCode(phone, util.HashToken("123456"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	// 并发使用同一验证码时只能成功一次
+	var passed int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if checkSmsCode(phone, "123456") == nil {
+				atomic.AddInt32(&passed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if passed != 1 {
+		t.Errorf("验证码成功使用次数 = %d, 期望 1", passed)
+	}
+}
+
+func TestSmsCodeExpired(t *testing.T) {
+	server := testutil.Setup(t)
+
+	const phone = "13800138002"
+	if err := redis().SetSmsCode(phone, util.HashToken("123456"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	server.FastForward(2 * time.Minute)
+	if resp := checkSmsCode(phone, "123456"); resp == nil || resp.ErrCode != 20041 {
+		t.Fatalf("过期验证码 = %+v", resp)
+	}
+	// 校验过期验证码不应重新创建不带过期时间的键
+	if server.Exists("sms_code:" + phone) {
+		t.Error("过期验证码被重新创建")
+	}
+}
+
+func TestSmsCodeMaxAttempts(t *testing.T) {
+	testutil.Setup(t)
+
+	const phone = "13800138003"
+	if err := redis().SetSmsCode(phone, util.HashToken("123456"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	for i := int64(0); i < conf.GetConfig().Sms.MaxAttempts; i++ {
+		if resp := checkSmsCode(phone, "000000"); resp == nil {
+			t.Fatal("错误验证码校验通过")
+		}
+	}
+	// 超过尝试次数后正确的验证码也已作废
+	if resp := checkSmsCode(phone, "123456"); resp == nil {
+		t.Error("超过尝试次数后验证码仍可使用")
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"errors"
+	"singo/cache"
+	"singo/conf"
+	"singo/data"
+	"singo/logger"
+	"singo/middleware"
+	"singo/req"
+	"singo/util"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	goRedis "github.com/go-redis/redis"
+)
+
+// errRefreshReused 刷新Token已被轮换过，疑似被盗用
+var errRefreshReused = errors.New("refresh token reused")
+
+// signAccessToken 签发访问Token
+func signAccessToken(username, sid string, roles []string, expire time.Duration) (string, error) {
+	claims := &middleware.Claims{
+		Username:  username,
+		SessionID: sid,
+		Roles:     roles,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(expire).Unix(),
+		},
+	}
+
+	return middleware.SignToken(claims)
+}
+
+// newSession 为登录设备创建会话信息
+func newSession(username, device string, client *req.Client) (*cache.Session, error) {
+	sid, err := util.RandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UnixMilli()
+	return &cache.Session{
+		ID:        sid,
+		UserName:  username,
+		Device:    device,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+		CreatedAt: now,
+		LastSeen:  now,
+	}, nil
+}
+
+// revokeUserTokens 撤销用户全部登录会话及OAuth2 Token
+func revokeUserTokens(username string) {
+	if err := redis().DelUserSessions(username); err != nil {
+		logger.Error("删除会话错误", err)
+	}
+	if err := redis().DelUserOAuthTokens(username); err != nil {
+		logger.Error("撤销OAuth2 Token错误", err)
+	}
+}
+
+// issueToken 颁发访问Token与刷新Token，prevHash为空时创建会话，否则在会话内轮换
+func issueToken(resp *data.UserReq, session *cache.Session, prevHash string) error {
+	server := conf.GetConfig().Server
+
+	accessToken, err := signAccessToken(resp.UserName, session.ID, resp.Roles, server.AccessExpire)
+	if err != nil {
+		return err
+	}
+
+	refreshToken, err := util.RandomToken(32)
+	if err != nil {
+		return err
+	}
+	refreshHash := util.HashToken(refreshToken)
+
+	if prevHash == "" {
+		session.Token = accessToken
+		session.Refresh = refreshHash
+		err = redis().CreateSession(session, server.RefreshExpire, server.MaxSessions)
+	} else {
+		var ok bool
+		ok, err = redis().RotateRefreshToken(resp.UserName, session.ID, prevHash, refreshHash, accessToken, server.RefreshExpire)
+		if err == nil && !ok {
+			err = errRefreshReused
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	resp.Token = accessToken
+	resp.TokenExpire = server.AccessExpire.Milliseconds()
+	resp.RefreshToken = refreshToken
+	resp.RefreshExpire = server.RefreshExpire.Milliseconds()
+	return nil
+}
+
+// @Description 刷新Token请求
+type TokenRefreshReq struct {
+	// 刷新Token
+	RefreshToken string `form:"refresh_token" json:"refresh_token" binding:"required"`
+}
+
+// RefreshToken 使用刷新Token换取新的Token，并轮换刷新Token
+func RefreshToken(service *TokenRefreshReq) *data.Response {
+	hash := util.HashToken(service.RefreshToken)
+	username, sid, err := redis().GetRefreshToken(hash)
+	if err != nil {
+		if !errors.Is(err, goRedis.Nil) {
+			logger.Error("查询刷新Token错误", err)
+		}
+		return data.NewErrorResponse(20006, "刷新Token无效")
+	}
+
+	session, err := redis().GetSession(sid)
+	if err != nil {
+		if !errors.Is(err, goRedis.Nil) {
+			logger.Error("查询会话错误", err)
+		}
+		return data.NewErrorResponse(20006, "刷新Token无效")
+	}
+	if session.Refresh != hash {
+		return revokeSession(username, sid)
+	}
+
+	user, err := rep().GetUser(username)
+	if err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+
+	resp := data.BuildUser(user)
+	if err = issueToken(resp, session, hash); err != nil {
+		if errors.Is(err, errRefreshReused) {
+			return revokeSession(username, sid)
+		}
+		logger.Error("颁发Token错误", err)
+		return data.NewErrorResponse(10000, "颁发Token错误")
+	}
+	return data.NewDataResponse(resp)
+}
+
+// revokeSession 检测到刷新Token重复使用，撤销整个会话
+func revokeSession(username, sid string) *data.Response {
+	logger.Warn("检测到刷新Token重复使用", username)
+	if err := redis().DelSession(username, sid); err != nil {
+		logger.Error("撤销会话错误", err)
+	}
+	return data.NewErrorResponse(20007, "刷新Token已失效，请重新登录")
+}
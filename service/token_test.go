@@ -0,0 +1,68 @@
+package service
+
+import (
+	"singo/data"
+	"singo/testutil"
+	"testing"
+)
+
+// passwordLogin 使用密码登录并返回Token
+func passwordLogin(t *testing.T, username, password string) *data.UserReq {
+	t.Helper()
+	resp := Login(&UserLoginReq{UserName: username, Password: password}, testClient)
+	user, ok := resp.Data.(*data.UserReq)
+	if !ok {
+		t.Fatalf("登录 = %+v", resp)
+	}
+	return user
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	testutil.Setup(t)
+	createTestUser(t, "alice01", "alice@example.com", "Gz8#kq2Lmv")
+	login := passwordLogin(t, "alice01", "Gz8#kq2Lmv")
+
+	resp := RefreshToken(&TokenRefreshReq{RefreshToken: login.RefreshToken})
+	if !resp.Success {
+		t.Fatalf("刷新Token = %+v", resp)
+	}
+	rotated := resp.Data.(*data.UserReq)
+	if rotated.RefreshToken == login.RefreshToken || rotated.Token == "" {
+		t.Errorf("刷新后Token未轮换 = %+v", rotated)
+	}
+	if resp = RefreshToken(&TokenRefreshReq{RefreshToken: rotated.RefreshToken}); !resp.Success {
+		t.Errorf("使用轮换后的刷新Token = %+v", resp)
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	testutil.Setup(t)
+	createTestUser(t, "alice01", "alice@example.com", "Gz8#kq2Lmv")
+	login := passwordLogin(t, "alice01", "Gz8#kq2Lmv")
+	other := passwordLogin(t, "alice01", "Gz8#kq2Lmv")
+
+	resp := RefreshToken(&TokenRefreshReq{RefreshToken: login.RefreshToken})
+	if !resp.Success {
+		t.Fatalf("刷新Token = %+v", resp)
+	}
+	rotated := resp.Data.(*data.UserReq)
+
+	// 已轮换的刷新Token被再次使用，视为被盗用并撤销整个会话
+	if resp = RefreshToken(&TokenRefreshReq{RefreshToken: login.RefreshToken}); resp.ErrCode != 20007 {
+		t.Fatalf("重复使用刷新Token = %+v", resp)
+	}
+	sessions, err := redis().ListSessions("alice01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("会话数量 = %d, 期望仅保留其他设备的会话", len(sessions))
+	}
+	if resp = RefreshToken(&TokenRefreshReq{RefreshToken: rotated.RefreshToken}); resp.Success {
+		t.Error("会话撤销后轮换的刷新Token仍可使用")
+	}
+	// 其他设备的会话不受影响
+	if resp = RefreshToken(&TokenRefreshReq{RefreshToken: other.RefreshToken}); !resp.Success {
+		t.Errorf("其他设备刷新Token = %+v", resp)
+	}
+}
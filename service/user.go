@@ -1,14 +1,14 @@
 package service
 
 import (
-	"github.com/dgrijalva/jwt-go"
-	"singo/conf"
+	"errors"
+	"singo/cache"
 	"singo/data"
 	"singo/logger"
-	"singo/middleware"
 	"singo/model"
 	"singo/req"
-	"time"
+
+	"gorm.io/gorm"
 )
 
 // @Description 用户注册请求
@@ -17,28 +17,41 @@ type UserRegisterReq struct {
 	Nickname string `form:"nickname" json:"nickname" binding:"required,min=2,max=30"`
 	// 用户名
 	UserName string `form:"user_name" json:"user_name" binding:"required,min=5,max=30"`
+	// 邮箱
+	Email string `form:"email" json:"email" binding:"required,email,max=100"`
 	// 密码
 	Password string `form:"password" json:"password" binding:"required,min=8,max=40"`
 	// 密码
 	PasswordConfirm string `form:"password_confirm" json:"password_confirm" binding:"required,min=8,max=40"`
 }
 
+// nicknameTaken 判断昵称是否已被其他用户占用，包括已删除的用户
+func nicknameTaken(nickname string, excludeID uint) bool {
+	count := int64(0)
+	rep().Unscoped().Model(&model.User{}).Where("nickname = ? AND id <> ?", nickname, excludeID).Count(&count)
+	return count > 0
+}
+
 // valid 验证表单
 func (service *UserRegisterReq) valid() *data.Response {
 	if service.PasswordConfirm != service.Password {
 		return data.NewErrorResponse(40001, "两次输入的密码不相同")
 	}
 
+	if nicknameTaken(service.Nickname, 0) {
+		return data.NewErrorResponse(40001, "昵称被占用")
+	}
+
 	count := int64(0)
-	rep().Model(&model.User{}).Where("nickname = ?", service.Nickname).Count(&count)
+	rep().Unscoped().Model(&model.User{}).Where("user_name = ?", service.UserName).Count(&count)
 	if count > 0 {
-		return data.NewErrorResponse(40001, "昵称被占用")
+		return data.NewErrorResponse(40001, "用户名已经注册")
 	}
 
 	count = 0
-	rep().Model(&model.User{}).Where("user_name = ?", service.UserName).Count(&count)
+	rep().Unscoped().Model(&model.User{}).Where("email = ?", service.Email).Count(&count)
 	if count > 0 {
-		return data.NewErrorResponse(40001, "用户名已经注册")
+		return data.NewErrorResponse(40001, "邮箱已经注册")
 	}
 
 	return nil
@@ -49,13 +62,17 @@ func Register(service *UserRegisterReq) *data.Response {
 	user := model.User{
 		Nickname: service.Nickname,
 		UserName: service.UserName,
-		Status:   model.Active,
+		Email:    service.Email,
+		Status:   model.Inactive,
 	}
 
 	// 表单验证
 	if resp := service.valid(); resp != nil {
 		return resp
 	}
+	if resp := checkPasswordPolicy(&user, service.Password); resp != nil {
+		return resp
+	}
 
 	// 加密密码
 	if err := user.SetPassword(service.Password); err != nil {
@@ -65,12 +82,17 @@ func Register(service *UserRegisterReq) *data.Response {
 		)
 	}
 
-	// 创建用户
-	if err := rep().Create(&user).Error; err != nil {
+	// 创建用户，未登录时操作人为注册的用户本人
+	if err := rep().As(user.UserName).Create(&user).Error; err != nil {
 		logger.Error("创建用户错误", err)
 		return data.NewErrorResponse(20001, "注册失败")
 	}
 
+	// 发送失败不影响注册，用户可重新发送
+	if err := sendVerifyMail(&user); err != nil {
+		logger.Error("发送验证邮件错误", err)
+	}
+
 	return data.NewDataResponse(data.BuildUser(&user))
 }
 
@@ -80,58 +102,137 @@ type UserLoginReq struct {
 	UserName string `form:"user_name" json:"user_name" binding:"required,min=5,max=30"`
 	// 密码
 	Password string `form:"password" json:"password" binding:"required,min=8,max=40"`
+	// 设备名称
+	Device string `form:"device" json:"device" binding:"max=50"`
 }
 
 // Login 用户登录函数
-func Login(service *UserLoginReq) *data.Response {
+func Login(service *UserLoginReq, client *req.Client) *data.Response {
+	userSubject := cache.LoginUserSubject(service.UserName)
+	if resp := loginThrottled(userSubject, cache.LoginIPSubject(client.IP)); resp != nil {
+		user, err := rep().GetUser(service.UserName)
+		if err != nil {
+			user = nil
+		}
+		recordLogin(user, service.UserName, service.Device, client, model.LoginThrottled)
+		return resp
+	}
+
 	user, err := rep().GetUser(service.UserName)
-	if err != nil {
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		logger.Error("查询用户错误", err)
 		return data.NewErrorResponse(20002, "查询用户失败")
 	}
 
+	// 用户不存在与密码错误返回相同的信息，避免泄露用户名是否存在
+	if err != nil {
+		// 注销宽限期内的账号密码正确时提示可恢复
+		if deleted, e := rep().GetPendingDeletionUser("user_name = ?", service.UserName); e == nil && deleted.CheckPassword(service.Password) {
+			recordLogin(deleted, service.UserName, service.Device, client, model.LoginPendingDeletion)
+			return pendingDeletionResponse()
+		}
+		checkDummyPassword(service.Password)
+		loginFailed(service.UserName, client.IP)
+		recordLogin(nil, service.UserName, service.Device, client, model.LoginUserNotFound)
+		return data.NewErrorResponse(20003, "账号或密码错误")
+	}
 	if !user.CheckPassword(service.Password) {
-		return data.NewErrorResponse(20003, "账号密码错误")
+		loginFailed(service.UserName, client.IP)
+		recordLogin(user, service.UserName, service.Device, client, model.LoginBadPassword)
+		return data.NewErrorResponse(20003, "账号或密码错误")
 	}
+	rehashPassword(user, service.Password)
+	return completeLogin(user, service.Device, client)
+}
 
-	expirationTime := time.Now().Add(2 * time.Hour)
-	claims := &middleware.Claims{
-		Username: user.UserName,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
-		},
+// completeLogin 身份校验通过后检查账号状态，开启两步验证的用户需要再校验验证码
+func completeLogin(user *model.User, device string, client *req.Client) *data.Response {
+	if resp := checkStatus(user); resp != nil {
+		reason := model.LoginSuspended
+		if user.Status == model.Inactive {
+			reason = model.LoginInactive
+		}
+		recordLogin(user, user.UserName, device, client, reason)
+		return resp
+	}
+	if user.MfaEnabled {
+		return mfaPending(user, device, client)
 	}
+	return loginSuccess(user, device, client)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(conf.GetConfig().Server.Secret))
+// loginSuccess 登录校验通过，为设备创建会话并颁发Token
+func loginSuccess(user *model.User, device string, client *req.Client) *data.Response {
+	resp := data.BuildUser(user)
+	session, err := newSession(user.UserName, device, client)
+	if err == nil {
+		err = issueToken(resp, session, "")
+	}
 	if err != nil {
 		logger.Error("颁发Token错误", err)
 		return data.NewErrorResponse(10000, "颁发Token错误")
 	}
-
-	resp := data.BuildUser(user)
-	resp.Token = tokenString
-	resp.TokenExpire = 2 * time.Hour.Milliseconds()
-
-	if err = redis().SetToken(user.UserName, resp.Token); err != nil {
-		logger.Error("存储Token错误", err)
-		return data.NewErrorResponse(10000, "颁发Token错误")
+	// 开启两步验证的用户在第二步校验通过后才清除失败次数
+	if err = redis().ClearLoginFail(cache.LoginUserSubject(user.UserName)); err != nil {
+		logger.Error("清除登录失败次数错误", err)
 	}
+	recordLogin(user, user.UserName, device, client, "")
 	return data.NewDataResponse(resp)
 }
 
-func Me(username string) *data.Response {
+// Me 获取个人信息，actor不为空表示当前为管理员模拟登录
+func Me(username, actor string) *data.Response {
 	user, err := rep().GetUser(username)
 	if err != nil {
 		return data.NewErrorResponse(2000, "查询个人信息错误")
 	}
-	return data.NewDataResponse(user)
+	resp := data.BuildUser(user)
+	resp.Impersonator = actor
+	return data.NewDataResponse(resp)
 }
 
+// GetAllUsers 按过滤条件分页查询用户
 func GetAllUsers(param *req.PageUserReq) *data.Response {
-	total, array, err := rep().GetUsers(param)
+	total, next, array, err := rep().GetUsers(param)
+	if errors.Is(err, model.ErrInvalidQuery) {
+		return data.ParamErr(err.Error())
+	}
 	if err != nil {
-		return nil
+		logger.Error("查询用户列表错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "查询用户列表失败")
+	}
+	return pageResponse(&param.PageReq, total, next, data.BuildUsers(array))
+}
+
+// Logout 用户注销，删除当前会话使Token立即失效
+func Logout(username, sid string) *data.Response {
+	if err := redis().DelSession(username, sid); err != nil {
+		logger.Error("删除Token错误", err)
+		return data.NewErrorResponse(20004, "注销失败")
+	}
+	// 模拟登录的Token同样在注销时失效
+	if err := redis().DelImpersonation(sid); err != nil {
+		logger.Error("删除模拟登录错误", err)
+	}
+	return data.NewSuccessResponse("注销成功")
+}
+
+// @Description 强制用户下线的请求
+type UserRevokeReq struct {
+	// 用户名
+	UserName string `form:"user_name" json:"user_name" binding:"required,min=5,max=30"`
+}
+
+// RevokeUser 管理员撤销用户的全部会话
+func RevokeUser(service *UserRevokeReq) *data.Response {
+	if _, err := rep().GetUser(service.UserName); err != nil {
+		logger.Error("查询用户错误", err)
+		return data.NewErrorResponse(20002, "查询用户失败")
+	}
+
+	if err := redis().DelUserSessions(service.UserName); err != nil {
+		logger.Error("删除Token错误", err)
+		return data.NewErrorResponse(20005, "撤销会话失败")
 	}
-	return data.NewPageResponse(total, array)
+	return data.NewSuccessResponse("撤销成功")
 }
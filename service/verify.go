@@ -0,0 +1,100 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"singo/conf"
+	"singo/data"
+	"singo/logger"
+	"singo/mailer"
+	"singo/model"
+	"singo/util"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// verifyPayload 验证链接签名内容 verify|用户名|邮箱|过期时间
+func verifyPayload(user *model.User, expireAt int64) string {
+	return strings.Join([]string{"verify", user.UserName, user.Email, strconv.FormatInt(expireAt, 10)}, "|")
+}
+
+// sendVerifyMail 发送邮箱验证链接
+func sendVerifyMail(user *model.User) error {
+	cfg := conf.GetConfig()
+	expireAt := time.Now().Add(cfg.Mail.VerifyExpire).Unix()
+	token := util.SignPayload(verifyPayload(user, expireAt), cfg.Server.Secret)
+	link := fmt.Sprintf("%s/api/v1/user/verify?token=%s", cfg.Server.BaseURL, url.QueryEscape(token))
+
+	body := fmt.Sprintf("%s，您好：\n\n请在%s内点击以下链接完成邮箱验证：\n%s\n\n如非本人操作请忽略本邮件。",
+		user.Nickname, cfg.Mail.VerifyExpire, link)
+	return mailer.GetMailer().Send(user.Email, "请验证您的邮箱", body)
+}
+
+// @Description 邮箱验证请求
+type UserVerifyReq struct {
+	// 验证Token
+	Token string `form:"token" json:"token" binding:"required"`
+}
+
+// Verify 校验邮箱验证链接并激活用户
+func Verify(service *UserVerifyReq) *data.Response {
+	payload, err := util.VerifyPayload(service.Token, conf.GetConfig().Server.Secret)
+	if err != nil {
+		return data.NewErrorResponse(20014, "验证链接无效")
+	}
+	parts := strings.Split(payload, "|")
+	if len(parts) != 4 || parts[0] != "verify" {
+		return data.NewErrorResponse(20014, "验证链接无效")
+	}
+	expireAt, _ := strconv.ParseInt(parts[3], 10, 64)
+	if time.Now().Unix() > expireAt {
+		return data.NewErrorResponse(20015, "验证链接已过期")
+	}
+
+	user, err := rep().GetUser(parts[1])
+	if err != nil || user.Email != parts[2] {
+		return data.NewErrorResponse(20014, "验证链接无效")
+	}
+	if user.Status != model.Inactive {
+		return data.NewSuccessResponse("邮箱已验证")
+	}
+
+	if err = rep().As(user.UserName).Model(user).Update("status", model.Active).Error; err != nil {
+		logger.Error("激活用户错误", err)
+		return data.NewErrorResponse(data.CodeDBError, "激活失败")
+	}
+	return data.NewSuccessResponse("邮箱验证成功")
+}
+
+// @Description 重发验证邮件请求
+type UserVerifyResendReq struct {
+	// 用户名
+	UserName string `form:"user_name" json:"user_name" binding:"required,min=5,max=30"`
+}
+
+// VerifyResend 重发邮箱验证邮件，无论用户是否存在都返回相同信息
+func VerifyResend(service *UserVerifyResendReq) *data.Response {
+	resp := data.NewSuccessResponse("如账号存在且未验证，验证邮件已发送")
+
+	user, err := rep().GetUser(service.UserName)
+	if err != nil || user.Status != model.Inactive {
+		return resp
+	}
+
+	ok, err := redis().Cooldown("verify_resend:"+user.UserName, conf.GetConfig().Mail.ResendInterval)
+	if err != nil {
+		logger.Error("查询发送频率错误", err)
+		return data.NewErrorResponse(20016, "发送失败")
+	}
+	// 发送过于频繁时同样返回通用信息，避免通过响应差异判断账号是否存在
+	if !ok {
+		return resp
+	}
+
+	if err = sendVerifyMail(user); err != nil {
+		logger.Error("发送验证邮件错误", err)
+		return data.NewErrorResponse(20016, "发送失败")
+	}
+	return resp
+}
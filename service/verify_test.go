@@ -0,0 +1,24 @@
+package service
+
+import (
+	"reflect"
+	"singo/model"
+	"singo/testutil"
+	"testing"
+)
+
+func TestVerifyResendThrottleIndistinguishable(t *testing.T) {
+	testutil.Setup(t)
+	user := createTestUser(t, "alice01", "alice@example.com", "Gz8#kq2Lmv")
+	if err := rep().Model(user).Update("status", model.Inactive).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	unknown := VerifyResend(&UserVerifyResendReq{UserName: "nobody01"})
+	for i := 0; i < 2; i++ {
+		// 发送过于频繁时的响应与不存在的账号相同
+		if resp := VerifyResend(&UserVerifyResendReq{UserName: "alice01"}); !reflect.DeepEqual(resp, unknown) {
+			t.Errorf("第%d次重发验证邮件 = %+v, 期望 %+v", i+1, resp, unknown)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package sign
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 签名相关请求头
+const (
+	HeaderAppKey    = "X-App-Key"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderNonce     = "X-Nonce"
+	HeaderSignature = "X-Signature"
+)
+
+// StringToSign 构造待签名字符串
+// 格式为 METHOD\nPATH\n排序后的查询参数\n时间戳(秒)\n随机数\n请求体SHA256
+func StringToSign(method, path string, query url.Values, timestamp, nonce string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		query.Encode(),
+		timestamp,
+		nonce,
+		hex.EncodeToString(sum[:]),
+	}, "\n")
+}
+
+// Signature 使用HMAC-SHA256计算签名，返回十六进制字符串
+func Signature(secret, stringToSign string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验签名
+func Verify(secret, stringToSign, signature string) bool {
+	expected := Signature(secret, stringToSign)
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
+
+// SignRequest 为请求添加签名请求头，请求体会被读取后重新写回
+func SignRequest(req *http.Request, appKey, secret string) error {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return err
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return err
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	toSign := StringToSign(req.Method, req.URL.Path, req.URL.Query(), timestamp, nonce, body)
+	req.Header.Set(HeaderAppKey, appKey)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderSignature, Signature(secret, toSign))
+	return nil
+}
+
+// Client 自动签名的开放接口客户端
+type Client struct {
+	BaseURL    string
+	AppKey     string
+	AppSecret  string
+	HTTPClient *http.Client
+}
+
+// NewClient 创建开放接口客户端
+func NewClient(baseURL, appKey, appSecret string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		AppKey:     appKey,
+		AppSecret:  appSecret,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Do 签名并发送请求，path为相对BaseURL的路径，可带查询参数
+func (c *Client) Do(method, path string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, c.BaseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if err = SignRequest(req, c.AppKey, c.AppSecret); err != nil {
+		return nil, err
+	}
+	return c.HTTPClient.Do(req)
+}
@@ -0,0 +1,42 @@
+package sms
+
+import (
+	"singo/logger"
+	"sync"
+)
+
+// LogSender 将短信输出到日志，用于开发环境
+type LogSender struct{}
+
+func (s *LogSender) Send(phone, content string) error {
+	logger.Info("发送短信 to:", phone, " content:", content)
+	return nil
+}
+
+// FakeSender 将短信保存在内存中，用于测试环境读取验证码
+type FakeSender struct {
+	mu       sync.Mutex
+	messages map[string][]string
+}
+
+func NewFakeSender() *FakeSender {
+	return &FakeSender{messages: make(map[string][]string)}
+}
+
+func (s *FakeSender) Send(phone, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages[phone] = append(s.messages[phone], content)
+	return nil
+}
+
+// Last 获取发送到手机号的最后一条短信
+func (s *FakeSender) Last(phone string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := s.messages[phone]
+	if len(messages) == 0 {
+		return ""
+	}
+	return messages[len(messages)-1]
+}
@@ -0,0 +1,28 @@
+package sms
+
+import (
+	"singo/conf"
+	"sync"
+)
+
+// SMSSender 短信发送接口，接入云厂商时实现该接口即可
+type SMSSender interface {
+	// Send 向手机号发送短信内容
+	Send(phone, content string) error
+}
+
+var sender SMSSender
+var senderOnce sync.Once
+
+// GetSender 根据配置获取短信发送器，开发环境使用log，测试环境可使用fake
+func GetSender() SMSSender {
+	senderOnce.Do(func() {
+		switch conf.GetConfig().Sms.Driver {
+		case "fake":
+			sender = NewFakeSender()
+		default:
+			sender = &LogSender{}
+		}
+	})
+	return sender
+}
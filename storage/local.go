@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage 将对象保存在本地目录，由服务自身提供静态访问
+type LocalStorage struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocalStorage(dir, baseURL string) *LocalStorage {
+	if dir == "" {
+		dir = "./uploads"
+	}
+	return &LocalStorage{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+func (s *LocalStorage) Put(key string, data []byte, contentType string) (string, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return s.baseURL + "/" + key, nil
+}
+
+func (s *LocalStorage) Get(key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) DeletePrefix(prefix string) error {
+	path, err := s.path(prefix)
+	if err != nil {
+		return err
+	}
+	// 以/结尾的前缀对应整个目录
+	if strings.HasSuffix(prefix, "/") {
+		return os.RemoveAll(path)
+	}
+	matches, err := filepath.Glob(path + "*")
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		if err = os.RemoveAll(match); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// path 将对象Key转换为本地路径，拒绝跳出存储目录的Key
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", errors.New("invalid object key")
+	}
+	return filepath.Join(s.dir, clean), nil
+}
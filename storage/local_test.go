@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalPath(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStorage(dir, "/uploads/")
+
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "avatars/1/a.png", want: filepath.Join(dir, "avatars/1/a.png")},
+		{key: "/avatars/1/a.png", want: filepath.Join(dir, "avatars/1/a.png")},
+		{key: "avatars//1/./a.png", want: filepath.Join(dir, "avatars/1/a.png")},
+		{key: "", wantErr: true},
+		{key: "/", wantErr: true},
+		{key: "..", wantErr: true},
+		{key: "../a.png", wantErr: true},
+		{key: "avatars/../../a.png", wantErr: true},
+		{key: "avatars/1/..", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := s.path(tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("path(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("path(%q) = %s, want %s", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStorage(dir, "/uploads/")
+
+	url, err := s.Put("avatars/1/a_64.png", []byte("png"), "image/png")
+	if err != nil || url != "/uploads/avatars/1/a_64.png" {
+		t.Fatalf("Put() = %s, %v", url, err)
+	}
+	if data, err := s.Get("avatars/1/a_64.png"); err != nil || string(data) != "png" {
+		t.Errorf("Get() = %s, %v", data, err)
+	}
+	if _, err = s.Put("../escape.png", []byte("png"), "image/png"); err == nil {
+		t.Error("跳出存储目录的Key应被拒绝")
+	}
+	if _, err = os.Stat(filepath.Join(filepath.Dir(dir), "escape.png")); !os.IsNotExist(err) {
+		t.Error("文件写到了存储目录之外")
+	}
+
+	for _, key := range []string{"avatars/1/a_256.png", "avatars/1/b_64.png", "avatars/12/c_64.png"} {
+		if _, err = s.Put(key, []byte("png"), "image/png"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 不以/结尾的前缀按文件名前缀删除
+	if err = s.DeletePrefix("avatars/1/a_"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Get("avatars/1/a_256.png"); err == nil {
+		t.Error("前缀匹配的文件未删除")
+	}
+	if _, err = s.Get("avatars/1/b_64.png"); err != nil {
+		t.Error("前缀不匹配的文件被删除")
+	}
+	// 以/结尾的前缀删除整个目录，不影响名称相近的目录
+	if err = s.DeletePrefix("avatars/1/"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "avatars/1")); !os.IsNotExist(err) {
+		t.Error("目录未删除")
+	}
+	if _, err = s.Get("avatars/12/c_64.png"); err != nil {
+		t.Error("其他用户的文件被删除")
+	}
+	if err = s.Delete("avatars/12/missing.png"); err != nil {
+		t.Errorf("删除不存在的文件 error = %v", err)
+	}
+	if err = s.DeletePrefix("../"); err == nil {
+		t.Error("跳出存储目录的前缀应被拒绝")
+	}
+}
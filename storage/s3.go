@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"singo/conf"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Storage S3兼容的对象存储，使用SigV4签名，支持MinIO等自建服务
+type S3Storage struct {
+	cfg    conf.S3Config
+	client *http.Client
+}
+
+func NewS3Storage(cfg conf.S3Config) *S3Storage {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	cfg.PublicURL = strings.TrimSuffix(cfg.PublicURL, "/")
+	return &S3Storage{cfg: cfg, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (s *S3Storage) Put(key string, data []byte, contentType string) (string, error) {
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	if _, err := s.do(http.MethodPut, s.objectURL(key), data, header); err != nil {
+		return "", err
+	}
+	if s.cfg.PublicURL != "" {
+		return s.cfg.PublicURL + "/" + key, nil
+	}
+	return s.objectURL(key), nil
+}
+
+func (s *S3Storage) Get(key string) ([]byte, error) {
+	return s.do(http.MethodGet, s.objectURL(key), nil, http.Header{})
+}
+
+func (s *S3Storage) Delete(key string) error {
+	_, err := s.do(http.MethodDelete, s.objectURL(key), nil, http.Header{})
+	return err
+}
+
+// listBucketResult ListObjectsV2的响应
+type listBucketResult struct {
+	Contents []struct {
+		Key string
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// DeletePrefix 使用ListObjectsV2分页列出前缀下的对象后逐个删除
+func (s *S3Storage) DeletePrefix(prefix string) error {
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		body, err := s.do(http.MethodGet, s.bucketURL()+"?"+query.Encode(), nil, http.Header{})
+		if err != nil {
+			return err
+		}
+		var result listBucketResult
+		if err = xml.Unmarshal(body, &result); err != nil {
+			return err
+		}
+		for _, object := range result.Contents {
+			if err = s.Delete(object.Key); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// objectURL 生成对象地址，path_style为true时使用 endpoint/bucket/key 格式
+func (s *S3Storage) objectURL(key string) string {
+	if s.cfg.PathStyle {
+		return fmt.Sprintf("%s/%s/%s", s.cfg.Endpoint, s.cfg.Bucket, escapePath(key))
+	}
+	u, err := url.Parse(s.cfg.Endpoint)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s://%s.%s/%s", u.Scheme, s.cfg.Bucket, u.Host, escapePath(key))
+}
+
+// bucketURL 生成存储桶地址，用于列出对象
+func (s *S3Storage) bucketURL() string {
+	if s.cfg.PathStyle {
+		return fmt.Sprintf("%s/%s", s.cfg.Endpoint, s.cfg.Bucket)
+	}
+	u, err := url.Parse(s.cfg.Endpoint)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s://%s.%s/", u.Scheme, s.cfg.Bucket, u.Host)
+}
+
+// do 发送签名后的请求并返回响应内容，删除时对象不存在视为成功
+func (s *S3Storage) do(method, rawURL string, body []byte, header http.Header) ([]byte, error) {
+	req, err := http.NewRequest(method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	signV4(req, body, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 && !(method == http.MethodDelete && resp.StatusCode == http.StatusNotFound) {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("s3 %s %s: %s %s", method, req.URL.Path, resp.Status, msg)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// signV4 按AWS Signature Version 4为请求添加签名，签名包含全部已设置的请求头
+func signV4(req *http.Request, body []byte, accessKey, secretKey, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, region)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath 按RFC3986编码路径，保留分隔符/
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// escape 按RFC3986编码，仅保留非保留字符
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
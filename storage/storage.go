@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"singo/conf"
+	"sync"
+)
+
+// Storage 对象存储接口
+type Storage interface {
+	// Put 保存对象并返回访问地址
+	Put(key string, data []byte, contentType string) (url string, err error)
+	// Get 读取对象内容
+	Get(key string) ([]byte, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(key string) error
+	// DeletePrefix 删除Key以prefix开头的全部对象
+	DeletePrefix(prefix string) error
+}
+
+var storage Storage
+var storageOnce sync.Once
+
+// GetStorage 根据配置获取对象存储，开发环境使用local，生产环境可使用S3兼容的存储
+func GetStorage() Storage {
+	storageOnce.Do(func() {
+		cfg := conf.GetConfig().Storage
+		switch cfg.Driver {
+		case "s3":
+			storage = NewS3Storage(cfg.S3)
+		default:
+			storage = NewLocalStorage(cfg.Local.Dir, cfg.Local.BaseURL)
+		}
+	})
+	return storage
+}
+
+// SetStorage 替换对象存储，测试时可使用临时目录或模拟的S3服务
+func SetStorage(s Storage) {
+	storageOnce.Do(func() {})
+	storage = s
+}
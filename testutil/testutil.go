@@ -0,0 +1,52 @@
+// Package testutil 为测试提供数据库及Redis的替身，无需连接真实服务
+package testutil
+
+import (
+	"path/filepath"
+	"singo/cache"
+	"singo/model"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SetupDB 使用临时目录中的SQLite数据库替换数据库连接，并迁移表结构
+func SetupDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	model.UseDb(db)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// SetupRedis 启动miniredis并替换Redis连接
+func SetupRedis(t testing.TB) *miniredis.Miniredis {
+	t.Helper()
+	server := miniredis.RunT(t)
+	cache.RedisClient = redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		_ = cache.RedisClient.Close()
+	})
+	return server
+}
+
+// Setup 同时替换数据库及Redis连接
+func Setup(t testing.TB) *miniredis.Miniredis {
+	t.Helper()
+	SetupDB(t)
+	return SetupRedis(t)
+}
@@ -0,0 +1,25 @@
+package util
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+// VerifyPKCE 按RFC 7636校验code_verifier，支持S256及plain
+func VerifyPKCE(verifier, challenge, method string) bool {
+	if verifier == "" || challenge == "" {
+		return false
+	}
+	expected := verifier
+	if method == "S256" {
+		expected = PKCEChallenge(verifier)
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// PKCEChallenge 按S256方式计算code_challenge
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
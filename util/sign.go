@@ -0,0 +1,44 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidSignature 签名校验失败
+var ErrInvalidSignature = errors.New("invalid signature")
+
+func hmacSum(payload, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// SignPayload 对内容进行HMAC签名，返回 base64(内容).base64(签名)
+func SignPayload(payload, secret string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." +
+		base64.RawURLEncoding.EncodeToString(hmacSum(payload, secret))
+}
+
+// VerifyPayload 校验SignPayload生成的字符串并返回原始内容
+func VerifyPayload(signed, secret string) (string, error) {
+	parts := strings.SplitN(signed, ".", 2)
+	if len(parts) != 2 {
+		return "", ErrInvalidSignature
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	if !hmac.Equal(sig, hmacSum(string(payload), secret)) {
+		return "", ErrInvalidSignature
+	}
+	return string(payload), nil
+}
@@ -0,0 +1,69 @@
+package util
+
+import "strings"
+
+// UserAgent 解析后的客户端信息
+type UserAgent struct {
+	// 浏览器
+	Browser string
+	// 操作系统
+	OS string
+	// 设备类型 desktop/mobile/tablet/bot
+	DeviceType string
+}
+
+// ParseUserAgent 按常见关键字粗略解析UA，无法识别时返回Other
+func ParseUserAgent(ua string) *UserAgent {
+	s := strings.ToLower(ua)
+	result := &UserAgent{
+		Browser:    "Other",
+		OS:         "Other",
+		DeviceType: "desktop",
+	}
+
+	// 顺序有意义：Edge、Opera的UA中同样包含Chrome，Chrome的UA中同样包含Safari
+	browsers := []struct{ key, name string }{
+		{"micromessenger", "WeChat"},
+		{"edg", "Edge"},
+		{"opr/", "Opera"},
+		{"firefox", "Firefox"},
+		{"chrome", "Chrome"},
+		{"crios", "Chrome"},
+		{"safari", "Safari"},
+		{"curl", "curl"},
+		{"okhttp", "OkHttp"},
+		{"go-http-client", "Go"},
+	}
+	for _, b := range browsers {
+		if strings.Contains(s, b.key) {
+			result.Browser = b.name
+			break
+		}
+	}
+
+	systems := []struct{ key, name string }{
+		{"windows", "Windows"},
+		{"iphone", "iOS"},
+		{"ipad", "iPadOS"},
+		{"android", "Android"},
+		{"mac os", "macOS"},
+		{"cros", "ChromeOS"},
+		{"linux", "Linux"},
+	}
+	for _, o := range systems {
+		if strings.Contains(s, o.key) {
+			result.OS = o.name
+			break
+		}
+	}
+
+	switch {
+	case strings.Contains(s, "bot") || strings.Contains(s, "spider") || strings.Contains(s, "crawl"):
+		result.DeviceType = "bot"
+	case strings.Contains(s, "ipad") || strings.Contains(s, "tablet"):
+		result.DeviceType = "tablet"
+	case strings.Contains(s, "mobile") || strings.Contains(s, "iphone") || strings.Contains(s, "android"):
+		result.DeviceType = "mobile"
+	}
+	return result
+}